@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/base64"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/deploymenttheory/go-installer-tools/internal/logger"
+	"github.com/deploymenttheory/go-installer-tools/internal/pkg/report"
+	"github.com/deploymenttheory/go-installer-tools/internal/pkg/sbom"
 	"github.com/deploymenttheory/go-installer-tools/internal/pkg/xar"
 	"github.com/deploymenttheory/go-installer-tools/internal/reader"
 )
@@ -14,8 +18,15 @@ import (
 func main() {
 	// Parse command line flags.
 	pkgPath := flag.String("pkg", "", "Path to the .pkg file to analyze")
+	url := flag.String("url", "", "URL of a .pkg file to analyze over HTTP(S), instead of -pkg")
 	checkSig := flag.Bool("check-signature", false, "Check if the package is signed")
+	computeHashes := flag.Bool("compute-hashes", false, "With -url, download the whole package to compute digests")
+	sbomFormat := flag.String("sbom", "", "Generate an SBOM in the given format (cyclonedx, spdx)")
+	sbomOut := flag.String("sbom-out", "", "Path to write the SBOM to (defaults to stdout)")
+	output := flag.String("output", "text", "Report output format (text, json, yaml)")
+	outputFile := flag.String("output-file", "", "Path to write the report to (defaults to stdout)")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	rootsFile := flag.String("roots-file", "", "With -check-signature, path to a PEM bundle (e.g. Apple's published Installer/Developer ID roots) to validate the signing chain against, instead of the system root certificates")
 	flag.Parse()
 
 	// Initialize logger with specified level.
@@ -24,87 +35,212 @@ func main() {
 	}
 	defer logger.Sync()
 
-	if *pkgPath == "" {
-		logger.Fatal("Please provide a path to a .pkg file using the -pkg flag")
+	if *pkgPath == "" && *url == "" {
+		logger.Fatal("Please provide a path to a .pkg file using the -pkg flag, or a URL using -url")
 	}
-
-	// Open the package file.
-	file, err := os.Open(*pkgPath)
-	if err != nil {
-		logger.Fatal("Error opening file",
-			"error", err,
-			"path", *pkgPath,
-		)
-	}
-	defer file.Close()
-
-	// Create a TempFileReader.
-	tfr := &reader.TempFileReader{Reader: file}
-
-	// Extract metadata.
-	metadata, err := xar.ExtractXARMetadata(tfr)
-	if err != nil {
-		logger.Fatal("Error extracting metadata",
-			"error", err,
-			"path", *pkgPath,
-		)
+	if *pkgPath != "" && *url != "" {
+		logger.Fatal("Please provide only one of -pkg or -url")
 	}
 
-	// Print the package report.
-	fmt.Printf("\nPackage Analysis Report\n")
-	fmt.Printf("=====================\n\n")
-
-	fmt.Printf("Main Package\n")
-	fmt.Printf("-----------\n")
-	fmt.Printf("Name: %s\n", metadata.Name)
-	fmt.Printf("Display Name: %s\n", metadata.DisplayName)
-	fmt.Printf("Bundle Name: %s\n", metadata.BundleName)
-	fmt.Printf("Version: %s\n", metadata.Version)
-	fmt.Printf("Primary Bundle Identifier: %s\n", metadata.PrimaryBundleIdentifier)
-	fmt.Printf("Minimum supported macOS Version: %s\n", metadata.MinimumOperatingSystemVersion)
-	fmt.Printf("Package IDs: %v\n", metadata.PackageIDs)
-	fmt.Printf("Supported Architecture(s): %s\n", metadata.HostArchitectures)
-	fmt.Printf("Primary Bundle Path: %s\n", metadata.PrimaryBundlePath)
-	fmt.Printf("PKG Size in MB: %.2f\n", metadata.PkgSizeMB)
-	fmt.Printf("SHA256: %s\n", base64.StdEncoding.EncodeToString(metadata.SHA256Sum))
-	fmt.Printf("MD5: %s\n", base64.StdEncoding.EncodeToString(metadata.MD5Sum))
-	fmt.Printf("SHA1: %s\n", base64.StdEncoding.EncodeToString(metadata.SHA1Sum))
+	var metadata *xar.PKGInstallerMetadata
+	var file *os.File // non-nil only for -pkg, where -check-signature needs the local file
 
-	// If any AppBundles were extracted, list them.
-	if len(metadata.AppBundles) > 0 {
-		fmt.Printf("\nApp Bundles\n")
-		fmt.Printf("-----------\n")
-		for i, ab := range metadata.AppBundles {
-			fmt.Printf("Bundle %d:\n", i+1)
-			fmt.Printf("  App Bundle ID: %s\n", ab.ID)
-			fmt.Printf("  CFBundleShortVersionString: %s\n", ab.ShortVersion)
-			fmt.Printf("  App Location Path: %s\n", ab.AppLocationPath)
+	if *url != "" {
+		var err error
+		metadata, err = xar.ExtractXARMetadataFromURL(context.Background(), *url, xar.ExtractOptions{
+			ComputeHashes: *computeHashes,
+		})
+		if err != nil {
+			logger.Fatal("Error extracting metadata", "error", err, "url", *url)
 		}
-	}
+		if *checkSig {
+			logger.Warn("-check-signature is not supported with -url; re-download the package and use -pkg instead")
+		}
+	} else {
+		var err error
+		file, err = os.Open(*pkgPath)
+		if err != nil {
+			logger.Fatal("Error opening file",
+				"error", err,
+				"path", *pkgPath,
+			)
+		}
+		defer file.Close()
 
-	// If signature check was requested, perform and print results.
-	if *checkSig {
-		// Rewind the file for signature check.
-		if _, err := file.Seek(0, 0); err != nil {
-			logger.Fatal("Error rewinding file",
+		// Create a TempFileReader.
+		tfr := reader.NewTempFileReader(file, reader.Options{})
+		defer tfr.Close()
+
+		metadata, err = xar.ExtractXARMetadata(tfr)
+		if err != nil {
+			logger.Fatal("Error extracting metadata",
 				"error", err,
 				"path", *pkgPath,
 			)
 		}
-		fmt.Printf("\nSignature Check\n")
-		fmt.Printf("--------------\n")
-		err := xar.CheckPKGSignature(file)
-		switch err {
-		case nil:
-			fmt.Printf("Status: Signed ✓\n")
-		case xar.ErrNotSigned:
-			fmt.Printf("Status: Not signed ✗\n")
-		case xar.ErrInvalidType:
-			fmt.Printf("Status: Invalid XAR package ✗\n")
-		default:
-			fmt.Printf("Status: Error checking signature: %v ✗\n", err)
+	}
+
+	// Generate the SBOM, if requested, before rendering the report so a
+	// structured report can carry a reference to it.
+	var sbomDoc []byte
+	var sbomRef string
+	if *sbomFormat != "" {
+		doc, err := sbom.Generate(sbom.Format(*sbomFormat), metadata, metadata.Components)
+		if err != nil {
+			logger.Fatal("Error generating SBOM", "error", err, "format", *sbomFormat)
+		}
+		sbomDoc = doc
+		if *sbomOut != "" {
+			sbomRef = *sbomOut
 		}
 	}
 
-	fmt.Println() // Add final newline
+	switch *output {
+	case "json", "yaml":
+		rpt := report.New(metadata, sbomRef)
+		doc, err := report.Render(rpt, report.Format(*output))
+		if err != nil {
+			logger.Fatal("Error rendering report", "error", err, "format", *output)
+		}
+		writeReport(doc, *outputFile)
+
+		if sbomDoc != nil {
+			if *sbomOut == "" {
+				fmt.Println(string(sbomDoc))
+			} else if err := os.WriteFile(*sbomOut, sbomDoc, 0o644); err != nil {
+				logger.Fatal("Error writing SBOM", "error", err, "path", *sbomOut)
+			}
+		}
+	case "text":
+		printTextReport(metadata, *outputFile)
+
+		// If signature check was requested, perform and print results.
+		if *checkSig && file != nil {
+			// Rewind the file for signature check.
+			if _, err := file.Seek(0, 0); err != nil {
+				logger.Fatal("Error rewinding file",
+					"error", err,
+					"path", *pkgPath,
+				)
+			}
+			fmt.Printf("\nSignature Check\n")
+			fmt.Printf("--------------\n")
+			err := xar.CheckPKGSignature(file)
+			switch err {
+			case nil:
+				fmt.Printf("Status: Signed ✓\n")
+
+				if _, err := file.Seek(0, 0); err != nil {
+					logger.Fatal("Error rewinding file", "error", err, "path", *pkgPath)
+				}
+
+				verifyOpts := xar.VerifyOptions{}
+				rootsDesc := "the system root certificates"
+				if *rootsFile != "" {
+					if roots, err := xar.RootsFromPEMFile(*rootsFile); err != nil {
+						logger.Fatal("Could not load -roots-file", "error", err, "path", *rootsFile)
+					} else {
+						verifyOpts.Roots = roots
+						rootsDesc = *rootsFile
+					}
+				} else if roots, err := xar.SystemRoots(); err != nil {
+					logger.Debug("Could not load system root certificates", "error", err)
+				} else {
+					verifyOpts.Roots = roots
+				}
+
+				if sigInfo, err := xar.VerifyPKGSignature(file, verifyOpts); err != nil {
+					fmt.Printf("Signer: could not verify signature: %v\n", err)
+				} else {
+					if sigInfo.SignerCN != "" {
+						fmt.Printf("Signer: %s\n", sigInfo.SignerCN)
+					}
+					if sigInfo.ChainTrusted {
+						fmt.Printf("Chain: trusted (validated against %s)\n", rootsDesc)
+					} else {
+						fmt.Printf("Chain: NOT validated - only the signature's internal consistency was checked, not the signer's identity\n")
+					}
+				}
+			case xar.ErrNotSigned:
+				fmt.Printf("Status: Not signed ✗\n")
+			case xar.ErrInvalidType:
+				fmt.Printf("Status: Invalid XAR package ✗\n")
+			default:
+				fmt.Printf("Status: Error checking signature: %v ✗\n", err)
+			}
+		}
+
+		if sbomDoc != nil {
+			if *sbomOut == "" {
+				fmt.Printf("\nSBOM (%s)\n", *sbomFormat)
+				fmt.Printf("--------------\n")
+				fmt.Println(string(sbomDoc))
+			} else if err := os.WriteFile(*sbomOut, sbomDoc, 0o644); err != nil {
+				logger.Fatal("Error writing SBOM", "error", err, "path", *sbomOut)
+			} else {
+				fmt.Printf("\nSBOM written to %s\n", *sbomOut)
+			}
+		}
+
+		fmt.Println() // Add final newline
+	default:
+		logger.Fatal("Unsupported -output format", "output", *output)
+	}
+}
+
+// printTextReport writes the human-readable package report either to
+// outputFile, or to stdout when outputFile is empty.
+func printTextReport(metadata *xar.PKGInstallerMetadata, outputFile string) {
+	var w io.Writer = os.Stdout
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			logger.Fatal("Error creating output file", "error", err, "path", outputFile)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	fmt.Fprintf(w, "\nPackage Analysis Report\n")
+	fmt.Fprintf(w, "=====================\n\n")
+
+	fmt.Fprintf(w, "Main Package\n")
+	fmt.Fprintf(w, "-----------\n")
+	fmt.Fprintf(w, "Name: %s\n", metadata.Name)
+	fmt.Fprintf(w, "Display Name: %s\n", metadata.DisplayName)
+	fmt.Fprintf(w, "Bundle Name: %s\n", metadata.BundleName)
+	fmt.Fprintf(w, "Version: %s\n", metadata.Version)
+	fmt.Fprintf(w, "Primary Bundle Identifier: %s\n", metadata.PrimaryBundleIdentifier)
+	fmt.Fprintf(w, "Minimum supported macOS Version: %s\n", metadata.MinimumOperatingSystemVersion)
+	fmt.Fprintf(w, "Package IDs: %v\n", metadata.PackageIDs)
+	fmt.Fprintf(w, "Supported Architecture(s): %s\n", metadata.HostArchitectures)
+	fmt.Fprintf(w, "Primary Bundle Path: %s\n", metadata.PrimaryBundlePath)
+	fmt.Fprintf(w, "PKG Size in MB: %.2f\n", metadata.PkgSizeMB)
+	fmt.Fprintf(w, "SHA256: %s\n", base64.StdEncoding.EncodeToString(metadata.SHA256Sum))
+	fmt.Fprintf(w, "MD5: %s\n", base64.StdEncoding.EncodeToString(metadata.MD5Sum))
+	fmt.Fprintf(w, "SHA1: %s\n", base64.StdEncoding.EncodeToString(metadata.SHA1Sum))
+
+	// If any AppBundles were extracted, list them.
+	if len(metadata.AppBundles) > 0 {
+		fmt.Fprintf(w, "\nApp Bundles\n")
+		fmt.Fprintf(w, "-----------\n")
+		for i, ab := range metadata.AppBundles {
+			fmt.Fprintf(w, "Bundle %d:\n", i+1)
+			fmt.Fprintf(w, "  App Bundle ID: %s\n", ab.ID)
+			fmt.Fprintf(w, "  CFBundleShortVersionString: %s\n", ab.ShortVersion)
+			fmt.Fprintf(w, "  App Location Path: %s\n", ab.AppLocationPath)
+		}
+	}
+}
+
+// writeReport writes an already-encoded structured report to outputFile, or
+// to stdout when outputFile is empty.
+func writeReport(doc []byte, outputFile string) {
+	if outputFile == "" {
+		fmt.Println(string(doc))
+		return
+	}
+	if err := os.WriteFile(outputFile, doc, 0o644); err != nil {
+		logger.Fatal("Error writing report", "error", err, "path", outputFile)
+	}
 }
@@ -1,10 +1,15 @@
 package crypto
 
 import (
+	stdcrypto "crypto"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
 	"io"
+
+	"golang.org/x/crypto/blake2b"
 )
 
 // ComputeSHA1 calculates the SHA1 checksum for the data read from r.
@@ -33,3 +38,70 @@ func ComputeSHA256(r io.Reader) ([]byte, error) {
 	}
 	return h.Sum(nil), nil
 }
+
+// MultiHasher computes several digests of the same data in a single pass,
+// so callers no longer need to re-read (or re-Rewind) a source once per
+// algorithm.
+type MultiHasher struct {
+	hashes map[stdcrypto.Hash]hash.Hash
+	w      io.Writer
+}
+
+// NewMultiHasher returns a MultiHasher that accumulates a digest for every
+// algorithm in algs as data is written to it. Supported algorithms are
+// crypto.MD5, crypto.SHA1, crypto.SHA256, crypto.SHA512, and
+// crypto.BLAKE2b_256; unsupported algorithms are ignored. With no
+// algorithms given, it defaults to crypto.SHA256.
+func NewMultiHasher(algs ...stdcrypto.Hash) *MultiHasher {
+	if len(algs) == 0 {
+		algs = []stdcrypto.Hash{stdcrypto.SHA256}
+	}
+
+	mh := &MultiHasher{hashes: make(map[stdcrypto.Hash]hash.Hash, len(algs))}
+	writers := make([]io.Writer, 0, len(algs))
+	for _, alg := range algs {
+		h := newHash(alg)
+		if h == nil {
+			continue
+		}
+		mh.hashes[alg] = h
+		writers = append(writers, h)
+	}
+	mh.w = io.MultiWriter(writers...)
+	return mh
+}
+
+func newHash(alg stdcrypto.Hash) hash.Hash {
+	switch alg {
+	case stdcrypto.MD5:
+		return md5.New()
+	case stdcrypto.SHA1:
+		return sha1.New()
+	case stdcrypto.SHA256:
+		return sha256.New()
+	case stdcrypto.SHA512:
+		return sha512.New()
+	case stdcrypto.BLAKE2b_256:
+		h, _ := blake2b.New256(nil) // nil key is always valid
+		return h
+	default:
+		return nil
+	}
+}
+
+// Write feeds p to every configured digest. It implements io.Writer so a
+// MultiHasher can be used as the destination of io.Copy or as one side of
+// an io.MultiWriter/io.TeeReader.
+func (m *MultiHasher) Write(p []byte) (int, error) {
+	return m.w.Write(p)
+}
+
+// Sums returns the current digest for each algorithm the MultiHasher was
+// constructed with.
+func (m *MultiHasher) Sums() map[stdcrypto.Hash][]byte {
+	sums := make(map[stdcrypto.Hash][]byte, len(m.hashes))
+	for alg, h := range m.hashes {
+		sums[alg] = h.Sum(nil)
+	}
+	return sums
+}
@@ -0,0 +1,118 @@
+package installer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// APKMetadata holds the fields extracted from an Alpine .apk archive's
+// .PKGINFO control file, beyond the CommonMetadata every backend populates.
+type APKMetadata struct {
+	CommonMetadata
+	// Origin is the source package name APKBUILD declares, if different
+	// from Name (e.g. for subpackages).
+	Origin string
+	// URL is the upstream project URL.
+	URL string
+	// License is the SPDX license expression APKBUILD declares.
+	License string
+}
+
+// Common implements Metadata.
+func (m *APKMetadata) Common() CommonMetadata { return m.CommonMetadata }
+
+// parseAPK extracts CommonMetadata and APKMetadata from an .apk archive: a
+// sequence of independently gzip-compressed tar streams concatenated back
+// to back - signature.tar.gz (v2 packages only), control.tar.gz, then
+// data.tar.gz. gzip.Reader's non-multistream mode plus Reset lets us step
+// from one member to the next without buffering the whole file, so the
+// (often large) data segment is never decoded at all; we stop as soon as
+// .PKGINFO turns up in a control segment.
+func parseAPK(r io.ReaderAt, size int64) (*APKMetadata, error) {
+	// One shared bufio.Reader (an io.ByteReader) keeps gzip from wrapping sr
+	// in a fresh buffer on every Reset, which would swallow the next member.
+	sr := bufio.NewReader(io.NewSectionReader(r, 0, size))
+
+	gz, err := gzip.NewReader(sr)
+	if err != nil {
+		return nil, fmt.Errorf("open apk gzip stream: %w", err)
+	}
+	gz.Multistream(false)
+
+	for {
+		data, found, err := findTarEntry(gz, ".PKGINFO")
+		if err != nil {
+			return nil, fmt.Errorf("read apk segment: %w", err)
+		}
+		if found {
+			return metadataFromPkgInfo(parsePkgInfo(data)), nil
+		}
+
+		// Consume the rest of this member (e.g. bytes after a tar
+		// end-of-archive marker) so Reset starts at the next one.
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			return nil, fmt.Errorf("read apk segment: %w", err)
+		}
+		if err := gz.Reset(sr); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("advance to next apk segment: %w", err)
+		}
+		gz.Multistream(false)
+	}
+
+	return nil, fmt.Errorf("apk archive has no .PKGINFO control file")
+}
+
+// parsePkgInfo parses an apk .PKGINFO file's "key = value" lines. Keys that
+// repeat (depend, provides) accumulate every value they were given.
+func parsePkgInfo(data []byte) map[string][]string {
+	fields := map[string][]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		fields[key] = append(fields[key], value)
+	}
+	return fields
+}
+
+// pkgInfoFirst returns the first value recorded for key, or "" if key
+// wasn't present.
+func pkgInfoFirst(fields map[string][]string, key string) string {
+	if v := fields[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func metadataFromPkgInfo(fields map[string][]string) *APKMetadata {
+	installSize, _ := strconv.ParseInt(pkgInfoFirst(fields, "size"), 10, 64)
+	return &APKMetadata{
+		CommonMetadata: CommonMetadata{
+			Name:             pkgInfoFirst(fields, "pkgname"),
+			Version:          pkgInfoFirst(fields, "pkgver"),
+			Architecture:     pkgInfoFirst(fields, "arch"),
+			Maintainer:       pkgInfoFirst(fields, "maintainer"),
+			Description:      pkgInfoFirst(fields, "pkgdesc"),
+			Depends:          fields["depend"],
+			Provides:         fields["provides"],
+			InstallSizeBytes: installSize,
+		},
+		Origin:  pkgInfoFirst(fields, "origin"),
+		URL:     pkgInfoFirst(fields, "url"),
+		License: pkgInfoFirst(fields, "license"),
+	}
+}
@@ -0,0 +1,72 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// arMagic is the fixed 8-byte signature every Unix ar archive (and
+// therefore every .deb, which is one) starts with.
+const arMagic = "!<arch>\n"
+
+// arHeaderSize is the size of each ar entry's fixed-width header,
+// immediately preceding its data.
+const arHeaderSize = 60
+
+// arEntry describes a single member of an ar archive.
+type arEntry struct {
+	name string
+	size int64
+	data io.Reader
+}
+
+// readAR parses the ar archive at r, which must span exactly size bytes,
+// returning its members in order. Entry data is exposed via lazily-read
+// io.SectionReaders rather than buffered up front, since deb archives are
+// small but their control.tar.* and data.tar.* members don't need to be
+// read at the same time.
+func readAR(r io.ReaderAt, size int64) ([]arEntry, error) {
+	magic := make([]byte, len(arMagic))
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("read ar magic: %w", err)
+	}
+	if string(magic) != arMagic {
+		return nil, fmt.Errorf("not an ar archive")
+	}
+
+	var entries []arEntry
+	off := int64(len(arMagic))
+	for off < size {
+		hdr := make([]byte, arHeaderSize)
+		if _, err := r.ReadAt(hdr, off); err != nil {
+			return nil, fmt.Errorf("read ar entry header at offset %d: %w", off, err)
+		}
+
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		name = strings.TrimSuffix(name, "/") // GNU ar pads short names with a trailing slash
+
+		entrySize, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse ar entry %q size: %w", name, err)
+		}
+		if entrySize < 0 {
+			return nil, fmt.Errorf("ar entry %q has negative size %d", name, entrySize)
+		}
+
+		dataOff := off + arHeaderSize
+		entries = append(entries, arEntry{
+			name: name,
+			size: entrySize,
+			data: io.NewSectionReader(r, dataOff, entrySize),
+		})
+
+		off = dataOff + entrySize
+		if entrySize%2 != 0 {
+			off++ // ar pads entry data to an even number of bytes
+		}
+	}
+
+	return entries, nil
+}
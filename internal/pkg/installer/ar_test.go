@@ -0,0 +1,51 @@
+package installer
+
+import (
+	"bytes"
+	"testing"
+)
+
+// arHeader builds a single 60-byte ar entry header with the given name and
+// size field, padded the way ar itself pads them.
+func arHeader(name, size string) []byte {
+	hdr := bytes.Repeat([]byte{' '}, arHeaderSize)
+	copy(hdr[0:16], name)
+	copy(hdr[48:58], size)
+	return hdr
+}
+
+// TestReadARRejectsNegativeEntrySize verifies that a crafted entry size
+// field like "-60", which would otherwise leave the read offset unchanged,
+// is rejected instead of looping forever re-reading the same header.
+func TestReadARRejectsNegativeEntrySize(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString(arMagic)
+	buf.Write(arHeader("evil", "-60"))
+
+	_, err := readAR(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err == nil {
+		t.Fatal("readAR did not reject a negative entry size")
+	}
+}
+
+// TestReadARParsesEntries verifies normal ar parsing still works.
+func TestReadARParsesEntries(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString(arMagic)
+	buf.Write(arHeader("debian-binary", "4"))
+	buf.WriteString("2.0\n")
+
+	entries, err := readAR(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("readAR: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if entries[0].name != "debian-binary" {
+		t.Errorf("name = %q, want %q", entries[0].name, "debian-binary")
+	}
+	if entries[0].size != 4 {
+		t.Errorf("size = %d, want 4", entries[0].size)
+	}
+}
@@ -0,0 +1,191 @@
+package installer
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// DebMetadata holds the fields extracted from a .deb archive's control
+// file, beyond the CommonMetadata every backend populates.
+type DebMetadata struct {
+	CommonMetadata
+	// Section is the Debian archive section, e.g. "utils" or "net".
+	Section string
+	// Priority is the package's Debian priority, e.g. "optional".
+	Priority string
+	// Homepage is the upstream project URL, if the control file declares one.
+	Homepage string
+}
+
+// Common implements Metadata.
+func (m *DebMetadata) Common() CommonMetadata { return m.CommonMetadata }
+
+// parseDeb extracts CommonMetadata and DebMetadata from a .deb archive: an
+// ar archive wrapping debian-binary, control.tar.*, and data.tar.*, per
+// https://manpages.debian.org/deb(5). Only control.tar.* is read.
+func parseDeb(r io.ReaderAt, size int64) (*DebMetadata, error) {
+	entries, err := readAR(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("read deb ar archive: %w", err)
+	}
+
+	var control *arEntry
+	for i := range entries {
+		if strings.HasPrefix(entries[i].name, "control.tar") {
+			control = &entries[i]
+			break
+		}
+	}
+	if control == nil {
+		return nil, fmt.Errorf("deb archive has no control.tar member")
+	}
+
+	stream, err := decompressTarStream(control.name, control.data)
+	if err != nil {
+		return nil, fmt.Errorf("decompress %s: %w", control.name, err)
+	}
+
+	controlData, found, err := findTarEntry(stream, "control")
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", control.name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%s has no control file", control.name)
+	}
+
+	fields := parseControlFile(controlData)
+	installSizeKB, _ := strconv.ParseInt(fields["Installed-Size"], 10, 64)
+
+	return &DebMetadata{
+		CommonMetadata: CommonMetadata{
+			Name:             fields["Package"],
+			Version:          fields["Version"],
+			Architecture:     fields["Architecture"],
+			Maintainer:       fields["Maintainer"],
+			Description:      fields["Description"],
+			Depends:          splitControlList(fields["Depends"]),
+			Provides:         splitControlList(fields["Provides"]),
+			InstallSizeBytes: installSizeKB * 1024, // Installed-Size is in KiB
+		},
+		Section:  fields["Section"],
+		Priority: fields["Priority"],
+		Homepage: fields["Homepage"],
+	}, nil
+}
+
+// decompressTarStream wraps r according to the compression implied by
+// name's suffix, covering every compressor dpkg-deb has used for
+// control.tar/data.tar over the years.
+func decompressTarStream(name string, r io.Reader) (io.Reader, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar"):
+		return r, nil
+	case strings.HasSuffix(name, ".tar.gz"):
+		return gzip.NewReader(r)
+	case strings.HasSuffix(name, ".tar.xz"):
+		return xz.NewReader(r)
+	case strings.HasSuffix(name, ".tar.bz2"):
+		return bzip2.NewReader(r), nil
+	case strings.HasSuffix(name, ".tar.zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported control archive compression: %q", name)
+	}
+}
+
+// maxControlFileSize bounds how much decompressed data findTarEntry will
+// read out of a single tar entry. Control files (deb's control, apk's
+// .PKGINFO) are always small plain-text metadata, so this guards against a
+// tar header declaring a huge Size backed by a small, highly compressible
+// payload - a decompression bomb wouldn't otherwise be capped until it was
+// already fully buffered in memory.
+const maxControlFileSize = 64 << 20 // 64 MiB
+
+// findTarEntry scans every entry of the tar stream r for name (ignoring a
+// leading "./", which dpkg-deb always adds), returning its contents if
+// present.
+func findTarEntry(r io.Reader, name string) ([]byte, bool, error) {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if strings.TrimPrefix(hdr.Name, "./") == name {
+			// Read one byte past the limit so an oversized entry can be
+			// told apart from one that exactly fits it.
+			data, err := io.ReadAll(io.LimitReader(tr, maxControlFileSize+1))
+			if err != nil {
+				return nil, false, err
+			}
+			if len(data) > maxControlFileSize {
+				return nil, false, fmt.Errorf("%s exceeds %d byte control file limit", name, maxControlFileSize)
+			}
+			return data, true, nil
+		}
+	}
+}
+
+// parseControlFile parses a Debian control file's RFC 822-style fields,
+// folding continuation lines (those starting with whitespace) into the
+// preceding field, which is how multi-line Description bodies are encoded.
+func parseControlFile(data []byte) map[string]string {
+	fields := map[string]string{}
+	lastKey := ""
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && lastKey != "" {
+			cont := strings.TrimLeft(line, " \t")
+			if cont == "." {
+				cont = ""
+			}
+			fields[lastKey] += "\n" + cont
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		fields[key] = strings.TrimSpace(value)
+		lastKey = key
+	}
+
+	return fields
+}
+
+// splitControlList splits a comma-separated Depends/Provides field into its
+// individual entries (version constraints left intact), trimming
+// whitespace and dropping empty entries.
+func splitControlList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+)
+
+// TestFindTarEntryEnforcesSizeLimit verifies that findTarEntry caps how much
+// of a tar entry it will buffer, rather than trusting the header's declared
+// Size - which a compressed tar stream can lie about cheaply.
+func TestFindTarEntryEnforcesSizeLimit(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	body := bytes.Repeat([]byte{'A'}, maxControlFileSize+1)
+	if err := tw.WriteHeader(&tar.Header{Name: "control", Size: int64(len(body))}); err != nil {
+		t.Fatalf("write tar header: %v", err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		t.Fatalf("write tar body: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar writer: %v", err)
+	}
+
+	if _, _, err := findTarEntry(bytes.NewReader(buf.Bytes()), "control"); err == nil {
+		t.Fatal("findTarEntry did not enforce the control file size limit")
+	}
+}
+
+// TestFindTarEntryWithinLimit verifies a normal, small control entry is
+// still read back correctly.
+func TestFindTarEntryWithinLimit(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	body := []byte("Package: test\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "./control", Size: int64(len(body))}); err != nil {
+		t.Fatalf("write tar header: %v", err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		t.Fatalf("write tar body: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar writer: %v", err)
+	}
+
+	data, found, err := findTarEntry(bytes.NewReader(buf.Bytes()), "control")
+	if err != nil {
+		t.Fatalf("findTarEntry: %v", err)
+	}
+	if !found {
+		t.Fatal("findTarEntry did not find the control entry")
+	}
+	if string(data) != string(body) {
+		t.Errorf("findTarEntry returned %q, want %q", data, body)
+	}
+}
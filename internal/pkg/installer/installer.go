@@ -0,0 +1,106 @@
+// Package installer identifies and parses the common Linux package
+// formats - deb, rpm, and apk - into a shared metadata model, the same way
+// the xar package does for macOS .pkg installers. The format matrix
+// mirrors nfpm's: deb is an ar archive wrapping a control.tar.*, rpm is the
+// classic lead/signature-header/header structure, and apk is a sequence of
+// gzip-compressed tar streams.
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Kind identifies which installer format Parse should decode r as.
+type Kind string
+
+const (
+	// KindDeb identifies a Debian/Ubuntu .deb package.
+	KindDeb Kind = "deb"
+	// KindRPM identifies a Red Hat/Fedora/SUSE .rpm package.
+	KindRPM Kind = "rpm"
+	// KindAPK identifies an Alpine .apk package.
+	KindAPK Kind = "apk"
+)
+
+// CommonMetadata holds the fields every supported installer format is able
+// to populate.
+type CommonMetadata struct {
+	// Name is the package's short name.
+	Name string
+	// Version is the package's version, including any release/revision
+	// suffix the format itself folds into a single version string.
+	Version string
+	// Architecture is the target CPU architecture, in the format's own
+	// naming (e.g. "amd64" for deb, "x86_64" for rpm, "x86_64" for apk).
+	Architecture string
+	// Maintainer identifies who packaged the release.
+	Maintainer string
+	// Description is the package's human-readable summary.
+	Description string
+	// Depends lists the other packages this package depends on, in the
+	// format's own dependency syntax (version constraints included).
+	Depends []string
+	// Provides lists the virtual packages or capabilities this package
+	// provides.
+	Provides []string
+	// InstallSizeBytes is the package's installed size, in bytes.
+	InstallSizeBytes int64
+}
+
+// Metadata is implemented by every installer format's metadata type. Common
+// returns the fields shared across formats; callers that need
+// format-specific detail (e.g. DebMetadata.Section) type-assert the
+// concrete value Parse returns.
+type Metadata interface {
+	Common() CommonMetadata
+}
+
+// Parse identifies and decodes the installer at r, which must span exactly
+// size bytes. If hint is non-empty, it is used directly instead of
+// sniffing the format from r's leading bytes.
+func Parse(r io.ReaderAt, size int64, hint Kind) (Metadata, error) {
+	kind := hint
+	if kind == "" {
+		detected, err := detect(r)
+		if err != nil {
+			return nil, err
+		}
+		kind = detected
+	}
+
+	switch kind {
+	case KindDeb:
+		return parseDeb(r, size)
+	case KindRPM:
+		return parseRPM(r, size)
+	case KindAPK:
+		return parseAPK(r, size)
+	default:
+		return nil, fmt.Errorf("unsupported installer kind: %q", kind)
+	}
+}
+
+// detect sniffs kind from r's leading bytes: the ar "!<arch>\n" magic for
+// deb, the RPM lead's magic for rpm, and the gzip magic for apk (every apk
+// is a sequence of gzip members).
+func detect(r io.ReaderAt) (Kind, error) {
+	magic := make([]byte, 8)
+	n, err := r.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("read magic: %w", err)
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte(arMagic)):
+		return KindDeb, nil
+	case bytes.HasPrefix(magic, rpmLeadMagic):
+		return KindRPM, nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return KindAPK, nil
+	default:
+		return "", fmt.Errorf("unrecognized installer format")
+	}
+}
@@ -0,0 +1,271 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// rpmLeadSize is the size of the fixed-format lead every rpm file starts
+// with (struct rpmlead in RPM's own source).
+const rpmLeadSize = 96
+
+// rpmLeadMagic is the lead's 4-byte magic.
+var rpmLeadMagic = []byte{0xED, 0xAB, 0xEE, 0xDB}
+
+// rpmHeaderMagic is the 3-byte magic starting both the signature header and
+// the main header that follow the lead.
+var rpmHeaderMagic = []byte{0x8E, 0xAD, 0xE8}
+
+// RPM header tags used to populate CommonMetadata and RPMMetadata. Values
+// match RPM's own rpmtag.h.
+const (
+	rpmTagName        = 1000
+	rpmTagVersion     = 1001
+	rpmTagRelease     = 1002
+	rpmTagSummary     = 1004
+	rpmTagDescription = 1005
+	rpmTagSize        = 1009
+	rpmTagVendor      = 1011
+	rpmTagLicense     = 1014
+	rpmTagPackager    = 1015
+	rpmTagGroup       = 1016
+	rpmTagURL         = 1020
+	rpmTagArch        = 1022
+	rpmTagProvideName = 1047
+	rpmTagRequireName = 1049
+)
+
+// RPM header entry type codes (RPM_*_TYPE in rpmtag.h).
+const (
+	rpmTypeInt32       = 4
+	rpmTypeInt64       = 5
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// RPMMetadata holds the fields extracted from an rpm's header section,
+// beyond the CommonMetadata every backend populates.
+type RPMMetadata struct {
+	CommonMetadata
+	// License is the package's license, e.g. "GPL-2.0-or-later".
+	License string
+	// Vendor identifies who built the package, e.g. "Fedora Project".
+	Vendor string
+	// Group is the rpm group classification, e.g. "Applications/System".
+	Group string
+	// URL is the upstream project URL.
+	URL string
+}
+
+// Common implements Metadata.
+func (m *RPMMetadata) Common() CommonMetadata { return m.CommonMetadata }
+
+// parseRPM extracts CommonMetadata and RPMMetadata from an rpm file's
+// lead, signature header, and header sections, per the layout described in
+// the LSB RPM file format specification.
+func parseRPM(r io.ReaderAt, size int64) (*RPMMetadata, error) {
+	lead := make([]byte, rpmLeadSize)
+	if _, err := r.ReadAt(lead, 0); err != nil {
+		return nil, fmt.Errorf("read rpm lead: %w", err)
+	}
+	if !bytes.Equal(lead[0:4], rpmLeadMagic) {
+		return nil, fmt.Errorf("not an rpm file")
+	}
+
+	_, sigEnd, err := readRPMHeader(r, rpmLeadSize, size)
+	if err != nil {
+		return nil, fmt.Errorf("read rpm signature header: %w", err)
+	}
+
+	// The main header starts 8-byte aligned after the signature header.
+	headerOff := (sigEnd + 7) &^ 7
+	hdr, _, err := readRPMHeader(r, headerOff, size)
+	if err != nil {
+		return nil, fmt.Errorf("read rpm header: %w", err)
+	}
+
+	version := hdr.string(rpmTagVersion)
+	if release := hdr.string(rpmTagRelease); release != "" {
+		version += "-" + release
+	}
+
+	description := hdr.string(rpmTagDescription)
+	if description == "" {
+		description = hdr.string(rpmTagSummary)
+	}
+
+	return &RPMMetadata{
+		CommonMetadata: CommonMetadata{
+			Name:             hdr.string(rpmTagName),
+			Version:          version,
+			Architecture:     hdr.string(rpmTagArch),
+			Maintainer:       hdr.string(rpmTagPackager),
+			Description:      description,
+			Depends:          hdr.stringArray(rpmTagRequireName),
+			Provides:         hdr.stringArray(rpmTagProvideName),
+			InstallSizeBytes: hdr.int64(rpmTagSize),
+		},
+		License: hdr.string(rpmTagLicense),
+		Vendor:  hdr.string(rpmTagVendor),
+		Group:   hdr.string(rpmTagGroup),
+		URL:     hdr.string(rpmTagURL),
+	}, nil
+}
+
+// rpmTagEntry is one index entry of an rpm header section, describing
+// where its value lives in the header's data store.
+type rpmTagEntry struct {
+	typ    uint32
+	offset uint32
+	count  uint32
+}
+
+// rpmHeader is a parsed rpm header section (used for both the signature
+// header and the main header - they share the same format).
+type rpmHeader struct {
+	entries map[int32]rpmTagEntry
+	data    []byte
+}
+
+// readRPMHeader parses the header section (intro + index + data store)
+// starting at off, returning it along with the absolute offset immediately
+// following its data store. size is the total rpm file size, used to reject
+// a corrupt or malicious nindex/hsize before allocating for it - the index
+// and data store can't legitimately be larger than the file they live in.
+func readRPMHeader(r io.ReaderAt, off int64, size int64) (*rpmHeader, int64, error) {
+	intro := make([]byte, 16)
+	if _, err := r.ReadAt(intro, off); err != nil {
+		return nil, 0, fmt.Errorf("read header intro: %w", err)
+	}
+	if !bytes.Equal(intro[0:3], rpmHeaderMagic) {
+		return nil, 0, fmt.Errorf("bad rpm header magic at offset %d", off)
+	}
+
+	nindex := binary.BigEndian.Uint32(intro[8:12])
+	hsize := binary.BigEndian.Uint32(intro[12:16])
+
+	indexOff := off + 16
+	indexLen := int64(nindex) * 16
+	if indexLen > size-indexOff {
+		return nil, 0, fmt.Errorf("rpm header at offset %d claims %d index entries, larger than the file", off, nindex)
+	}
+	indexBytes := make([]byte, indexLen)
+	if nindex > 0 {
+		if _, err := r.ReadAt(indexBytes, indexOff); err != nil {
+			return nil, 0, fmt.Errorf("read header index: %w", err)
+		}
+	}
+
+	dataOff := indexOff + indexLen
+	if int64(hsize) > size-dataOff {
+		return nil, 0, fmt.Errorf("rpm header at offset %d claims a %d byte data store, larger than the file", off, hsize)
+	}
+	data := make([]byte, hsize)
+	if hsize > 0 {
+		if _, err := r.ReadAt(data, dataOff); err != nil {
+			return nil, 0, fmt.Errorf("read header data: %w", err)
+		}
+	}
+
+	entries := make(map[int32]rpmTagEntry, nindex)
+	for i := uint32(0); i < nindex; i++ {
+		b := indexBytes[i*16 : i*16+16]
+		entries[int32(binary.BigEndian.Uint32(b[0:4]))] = rpmTagEntry{
+			typ:    binary.BigEndian.Uint32(b[4:8]),
+			offset: binary.BigEndian.Uint32(b[8:12]),
+			count:  binary.BigEndian.Uint32(b[12:16]),
+		}
+	}
+
+	return &rpmHeader{entries: entries, data: data}, dataOff + int64(hsize), nil
+}
+
+// string returns the scalar string value of tag (STRING, I18NSTRING, or the
+// first element of a STRING_ARRAY), or "" if tag isn't present.
+func (h *rpmHeader) string(tag int32) string {
+	e, ok := h.entries[tag]
+	if !ok {
+		return ""
+	}
+	switch e.typ {
+	case rpmTypeString, rpmTypeI18NString:
+		return cString(h.data, e.offset)
+	case rpmTypeStringArray:
+		if arr := stringArrayAt(h.data, e.offset, 1); len(arr) > 0 {
+			return arr[0]
+		}
+	}
+	return ""
+}
+
+// stringArray returns the STRING_ARRAY value of tag, or nil if tag isn't
+// present or isn't a string array.
+func (h *rpmHeader) stringArray(tag int32) []string {
+	e, ok := h.entries[tag]
+	if !ok || e.typ != rpmTypeStringArray {
+		return nil
+	}
+	return stringArrayAt(h.data, e.offset, e.count)
+}
+
+// int64 returns the scalar integer value of tag (INT32 or INT64), or 0 if
+// tag isn't present or isn't an integer type.
+func (h *rpmHeader) int64(tag int32) int64 {
+	e, ok := h.entries[tag]
+	if !ok {
+		return 0
+	}
+	switch e.typ {
+	case rpmTypeInt32:
+		if int(e.offset)+4 <= len(h.data) {
+			return int64(binary.BigEndian.Uint32(h.data[e.offset:]))
+		}
+	case rpmTypeInt64:
+		if int(e.offset)+8 <= len(h.data) {
+			return int64(binary.BigEndian.Uint64(h.data[e.offset:]))
+		}
+	}
+	return 0
+}
+
+// cString reads a single NUL-terminated string out of data starting at
+// offset.
+func cString(data []byte, offset uint32) string {
+	if int(offset) >= len(data) {
+		return ""
+	}
+	rest := data[offset:]
+	if end := bytes.IndexByte(rest, 0); end >= 0 {
+		return string(rest[:end])
+	}
+	return string(rest)
+}
+
+// stringArrayAt reads count consecutive NUL-terminated strings out of data
+// starting at offset, stopping early if the data runs out or a terminator
+// is missing. count comes straight from the untrusted header index, so it's
+// capped against the data remaining after offset before being used as an
+// allocation size - every string takes at least one byte, so it can never
+// legitimately exceed that.
+func stringArrayAt(data []byte, offset, count uint32) []string {
+	if int(offset) > len(data) {
+		return nil
+	}
+	if remaining := uint32(len(data) - int(offset)); count > remaining {
+		count = remaining
+	}
+	out := make([]string, 0, count)
+	pos := int(offset)
+	for i := uint32(0); i < count && pos < len(data); i++ {
+		end := bytes.IndexByte(data[pos:], 0)
+		if end < 0 {
+			break
+		}
+		out = append(out, string(data[pos:pos+end]))
+		pos += end + 1
+	}
+	return out
+}
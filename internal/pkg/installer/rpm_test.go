@@ -0,0 +1,85 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestReadRPMHeaderRejectsOversizedClaims verifies that a header intro
+// claiming an index or data store larger than the file itself is rejected
+// before any allocation is made for it.
+func TestReadRPMHeaderRejectsOversizedClaims(t *testing.T) {
+	makeIntro := func(nindex, hsize uint32) []byte {
+		intro := make([]byte, 16)
+		copy(intro[0:3], rpmHeaderMagic)
+		binary.BigEndian.PutUint32(intro[8:12], nindex)
+		binary.BigEndian.PutUint32(intro[12:16], hsize)
+		return intro
+	}
+
+	t.Run("oversized index", func(t *testing.T) {
+		data := makeIntro(1<<28, 0)
+		r := bytes.NewReader(data)
+		if _, _, err := readRPMHeader(r, 0, int64(len(data))); err == nil {
+			t.Fatal("expected an error for an index claiming far more entries than the file could hold")
+		}
+	})
+
+	t.Run("oversized data store", func(t *testing.T) {
+		data := makeIntro(0, 1<<30)
+		r := bytes.NewReader(data)
+		if _, _, err := readRPMHeader(r, 0, int64(len(data))); err == nil {
+			t.Fatal("expected an error for a data store claiming far more bytes than the file could hold")
+		}
+	})
+
+	t.Run("valid header", func(t *testing.T) {
+		intro := makeIntro(1, 4)
+		entry := make([]byte, 16)
+		binary.BigEndian.PutUint32(entry[0:4], rpmTagSize)
+		binary.BigEndian.PutUint32(entry[4:8], rpmTypeInt32)
+		binary.BigEndian.PutUint32(entry[8:12], 0)
+		binary.BigEndian.PutUint32(entry[12:16], 1)
+		value := make([]byte, 4)
+		binary.BigEndian.PutUint32(value, 42)
+
+		var buf bytes.Buffer
+		buf.Write(intro)
+		buf.Write(entry)
+		buf.Write(value)
+
+		hdr, end, err := readRPMHeader(bytes.NewReader(buf.Bytes()), 0, int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("readRPMHeader: %v", err)
+		}
+		if end != int64(buf.Len()) {
+			t.Errorf("end = %d, want %d", end, buf.Len())
+		}
+		if got := hdr.int64(rpmTagSize); got != 42 {
+			t.Errorf("int64(rpmTagSize) = %d, want 42", got)
+		}
+	})
+}
+
+// TestStringArrayAtBoundsCount verifies that a tag entry claiming far more
+// array elements than the header's data store could possibly hold doesn't
+// drive an oversized allocation before any byte is read.
+func TestStringArrayAtBoundsCount(t *testing.T) {
+	data := []byte("a\x00b\x00")
+
+	got := stringArrayAt(data, 0, 0xFFFFFFFF)
+	want := []string{"a", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("stringArrayAt returned %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("stringArrayAt()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if got := stringArrayAt(data, uint32(len(data))+1, 0xFFFFFFFF); got != nil {
+		t.Errorf("stringArrayAt with out-of-range offset = %v, want nil", got)
+	}
+}
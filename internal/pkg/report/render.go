@@ -0,0 +1,30 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format selects the encoding Render produces.
+type Format string
+
+const (
+	// FormatJSON renders the report as indented JSON.
+	FormatJSON Format = "json"
+	// FormatYAML renders the report as YAML.
+	FormatYAML Format = "yaml"
+)
+
+// Render encodes r in the requested format.
+func Render(r *PKGReport, format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(r, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(r)
+	default:
+		return nil, fmt.Errorf("unsupported report format: %q", format)
+	}
+}
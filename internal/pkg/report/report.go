@@ -0,0 +1,153 @@
+// Package report defines the structured output emitted by the CLI's
+// -output json and -output yaml modes, so that tooling (Fleet/Jamf/Intune
+// uploaders, CI pipelines) can consume a parsed .pkg's metadata without
+// scraping the human-readable text report.
+package report
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/deploymenttheory/go-installer-tools/internal/pkg/xar"
+)
+
+// Hash reports a single digest in both common textual encodings, since
+// different downstream tools expect one or the other.
+type Hash struct {
+	Hex    string `json:"hex,omitempty" yaml:"hex,omitempty"`
+	Base64 string `json:"base64,omitempty" yaml:"base64,omitempty"`
+}
+
+func newHash(sum []byte) Hash {
+	if len(sum) == 0 {
+		return Hash{}
+	}
+	return Hash{
+		Hex:    hex.EncodeToString(sum),
+		Base64: base64.StdEncoding.EncodeToString(sum),
+	}
+}
+
+// Hashes collects every digest PKGInstallerMetadata may carry.
+type Hashes struct {
+	SHA256     Hash `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	SHA1       Hash `json:"sha1,omitempty" yaml:"sha1,omitempty"`
+	MD5        Hash `json:"md5,omitempty" yaml:"md5,omitempty"`
+	SHA512     Hash `json:"sha512,omitempty" yaml:"sha512,omitempty"`
+	BLAKE2b256 Hash `json:"blake2b256,omitempty" yaml:"blake2b256,omitempty"`
+}
+
+// Size reports a byte count both as a raw number and in human-readable form
+// in binary units (e.g. "12.34 MiB"), so callers don't each have to reimplement the
+// conversion.
+type Size struct {
+	Bytes int64  `json:"bytes" yaml:"bytes"`
+	Human string `json:"human" yaml:"human"`
+}
+
+func newSize(bytes int64) Size {
+	return Size{Bytes: bytes, Human: humanBytes(bytes)}
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Signature summarizes a package's xar signature, if any.
+type Signature struct {
+	Signed             bool     `json:"signed" yaml:"signed"`
+	SignerCN           string   `json:"signerCn,omitempty" yaml:"signerCn,omitempty"`
+	SignatureAlgorithm string   `json:"signatureAlgorithm,omitempty" yaml:"signatureAlgorithm,omitempty"`
+	Certificates       []string `json:"certificates,omitempty" yaml:"certificates,omitempty"`
+}
+
+// PKGReport is the stable, structured summary of a parsed .pkg installer.
+// It wraps PKGInstallerMetadata with encodings (hashes in hex and base64,
+// sizes in bytes and human-readable) that are awkward to compute from the
+// raw metadata, plus a pointer to any SBOM generated alongside it. See
+// schema.json for the JSON Schema describing this type.
+type PKGReport struct {
+	Name                          string            `json:"name" yaml:"name"`
+	ApplicationTitle              string            `json:"applicationTitle,omitempty" yaml:"applicationTitle,omitempty"`
+	DisplayName                   string            `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	BundleName                    string            `json:"bundleName,omitempty" yaml:"bundleName,omitempty"`
+	Version                       string            `json:"version,omitempty" yaml:"version,omitempty"`
+	PrimaryBundleIdentifier       string            `json:"primaryBundleIdentifier,omitempty" yaml:"primaryBundleIdentifier,omitempty"`
+	PackageIDs                    []string          `json:"packageIds,omitempty" yaml:"packageIds,omitempty"`
+	MinimumOperatingSystemVersion string            `json:"minimumOperatingSystemVersion,omitempty" yaml:"minimumOperatingSystemVersion,omitempty"`
+	HostArchitectures             string            `json:"hostArchitectures,omitempty" yaml:"hostArchitectures,omitempty"`
+	PrimaryBundlePath             string            `json:"primaryBundlePath,omitempty" yaml:"primaryBundlePath,omitempty"`
+	Size                          Size              `json:"size" yaml:"size"`
+	Hashes                        Hashes            `json:"hashes" yaml:"hashes"`
+	Signature                     Signature         `json:"signature" yaml:"signature"`
+	AppBundles                    []xar.AppBundle   `json:"appBundles,omitempty" yaml:"appBundles,omitempty"`
+	Files                         []xar.PayloadFile `json:"files,omitempty" yaml:"files,omitempty"`
+	SBOMRef                       string            `json:"sbomRef,omitempty" yaml:"sbomRef,omitempty"`
+	Components                    []*PKGReport      `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// New builds a PKGReport from parsed metadata. sbomRef, if non-empty, is
+// carried through as a pointer to an SBOM generated for the same package
+// (typically a file path or URL) and is not itself validated.
+func New(meta *xar.PKGInstallerMetadata, sbomRef string) *PKGReport {
+	if meta == nil {
+		return nil
+	}
+
+	r := &PKGReport{
+		Name:                          meta.Name,
+		ApplicationTitle:              meta.ApplicationTitle,
+		DisplayName:                   meta.DisplayName,
+		BundleName:                    meta.BundleName,
+		Version:                       meta.Version,
+		PrimaryBundleIdentifier:       meta.PrimaryBundleIdentifier,
+		PackageIDs:                    meta.PackageIDs,
+		MinimumOperatingSystemVersion: meta.MinimumOperatingSystemVersion,
+		HostArchitectures:             meta.HostArchitectures,
+		PrimaryBundlePath:             meta.PrimaryBundlePath,
+		Size:                          newSize(int64(meta.PkgSizeMB * 1024 * 1024)),
+		AppBundles:                    meta.AppBundles,
+		Files:                         meta.Files,
+		SBOMRef:                       sbomRef,
+		Hashes: Hashes{
+			SHA256:     newHash(meta.SHA256Sum),
+			SHA1:       newHash(meta.SHA1Sum),
+			MD5:        newHash(meta.MD5Sum),
+			SHA512:     newHash(meta.SHA512Sum),
+			BLAKE2b256: newHash(meta.BLAKE2b256Sum),
+		},
+		Signature: Signature{
+			Signed:             meta.IsSigned,
+			SignerCN:           meta.SignerCN,
+			SignatureAlgorithm: meta.SignatureAlgorithm,
+			Certificates:       encodeCertificates(meta.Certificates),
+		},
+	}
+
+	for _, c := range meta.Components {
+		r.Components = append(r.Components, New(c, ""))
+	}
+
+	return r
+}
+
+func encodeCertificates(certs [][]byte) []string {
+	if len(certs) == 0 {
+		return nil
+	}
+	out := make([]string, len(certs))
+	for i, c := range certs {
+		out[i] = base64.StdEncoding.EncodeToString(c)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package sbom
+
+import (
+	"encoding/json"
+
+	"github.com/deploymenttheory/go-installer-tools/internal/pkg/xar"
+)
+
+// cyclonedxDocument is a minimal CycloneDX 1.5 BOM: just enough structure to
+// describe a .pkg and its components for downstream tools like
+// Grype/Trivy/Dependency-Track.
+type cyclonedxDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    cyclonedxMetadata    `json:"metadata"`
+	Components  []cyclonedxComponent `json:"components,omitempty"`
+}
+
+type cyclonedxMetadata struct {
+	Component cyclonedxComponent `json:"component"`
+}
+
+type cyclonedxComponent struct {
+	Type               string                 `json:"type"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version,omitempty"`
+	PackageURL         string                 `json:"purl,omitempty"`
+	Hashes             []cyclonedxHash        `json:"hashes,omitempty"`
+	ExternalReferences []cyclonedxExternalRef `json:"externalReferences,omitempty"`
+}
+
+type cyclonedxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cyclonedxExternalRef struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func cyclonedxComponentFor(meta *xar.PKGInstallerMetadata) cyclonedxComponent {
+	c := cyclonedxComponent{
+		Type:       "application",
+		Name:       meta.Name,
+		Version:    meta.Version,
+		PackageURL: purl(meta),
+	}
+	if h := hexHash(meta.SHA256Sum); h != "" {
+		c.Hashes = append(c.Hashes, cyclonedxHash{Algorithm: "SHA-256", Content: h})
+	}
+	if h := hexHash(meta.SHA1Sum); h != "" {
+		c.Hashes = append(c.Hashes, cyclonedxHash{Algorithm: "SHA-1", Content: h})
+	}
+	if h := hexHash(meta.MD5Sum); h != "" {
+		c.Hashes = append(c.Hashes, cyclonedxHash{Algorithm: "MD5", Content: h})
+	}
+	if meta.SignerCN != "" {
+		c.ExternalReferences = append(c.ExternalReferences, cyclonedxExternalRef{
+			Type:    "other",
+			URL:     "urn:signer:" + meta.SignerCN,
+			Comment: "signer common name",
+		})
+	}
+	if meta.MinimumOperatingSystemVersion != "" {
+		c.ExternalReferences = append(c.ExternalReferences, cyclonedxExternalRef{
+			Type:    "other",
+			URL:     "urn:macos-minimum-version:" + meta.MinimumOperatingSystemVersion,
+			Comment: "LSMinimumSystemVersion",
+		})
+	}
+	return c
+}
+
+func generateCycloneDX(meta *xar.PKGInstallerMetadata, components []*xar.PKGInstallerMetadata) ([]byte, error) {
+	doc := cyclonedxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata:    cyclonedxMetadata{Component: cyclonedxComponentFor(meta)},
+	}
+	for _, c := range components {
+		doc.Components = append(doc.Components, cyclonedxComponentFor(c))
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
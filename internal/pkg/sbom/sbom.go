@@ -0,0 +1,55 @@
+// Package sbom generates software bill-of-materials documents describing
+// the contents of a parsed .pkg installer.
+package sbom
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/deploymenttheory/go-installer-tools/internal/pkg/xar"
+)
+
+// Format selects which SBOM standard Generate produces.
+type Format string
+
+const (
+	// FormatCycloneDX produces a CycloneDX 1.5 JSON document.
+	FormatCycloneDX Format = "cyclonedx"
+	// FormatSPDX produces an SPDX 2.3 JSON document.
+	FormatSPDX Format = "spdx"
+)
+
+// Generate builds an SBOM document in the requested format describing meta
+// and its component packages (e.g. nested component .pkg archives found
+// inside a distribution package's XAR TOC).
+func Generate(format Format, meta *xar.PKGInstallerMetadata, components []*xar.PKGInstallerMetadata) ([]byte, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("nil metadata")
+	}
+
+	switch format {
+	case FormatCycloneDX:
+		return generateCycloneDX(meta, components)
+	case FormatSPDX:
+		return generateSPDX(meta, components)
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format: %q", format)
+	}
+}
+
+// purl returns the package URL identifying meta, e.g.
+// "pkg:macos/com.example.app@1.2.3".
+func purl(meta *xar.PKGInstallerMetadata) string {
+	id := meta.PrimaryBundleIdentifier
+	if id == "" {
+		id = meta.Name
+	}
+	return fmt.Sprintf("pkg:macos/%s@%s", id, meta.Version)
+}
+
+func hexHash(sum []byte) string {
+	if len(sum) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(sum)
+}
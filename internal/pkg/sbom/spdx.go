@@ -0,0 +1,78 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/deploymenttheory/go-installer-tools/internal/pkg/xar"
+)
+
+// spdxDocument is a minimal SPDX 2.3 JSON document describing a .pkg and its
+// components.
+type spdxDocument struct {
+	SPDXVersion       string        `json:"spdxVersion"`
+	DataLicense       string        `json:"dataLicense"`
+	SPDXID            string        `json:"SPDXID"`
+	Name              string        `json:"name"`
+	DocumentNamespace string        `json:"documentNamespace"`
+	Packages          []spdxPackage `json:"packages"`
+}
+
+type spdxPackage struct {
+	SPDXID       string            `json:"SPDXID"`
+	Name         string            `json:"name"`
+	VersionInfo  string            `json:"versionInfo,omitempty"`
+	ExternalRefs []spdxExternalRef `json:"externalRefs,omitempty"`
+	Checksums    []spdxChecksum    `json:"checksums,omitempty"`
+	Comment      string            `json:"comment,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+func spdxPackageFor(meta *xar.PKGInstallerMetadata, spdxID string) spdxPackage {
+	p := spdxPackage{
+		SPDXID:      spdxID,
+		Name:        meta.Name,
+		VersionInfo: meta.Version,
+		ExternalRefs: []spdxExternalRef{
+			{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl(meta)},
+		},
+	}
+	if h := hexHash(meta.SHA256Sum); h != "" {
+		p.Checksums = append(p.Checksums, spdxChecksum{Algorithm: "SHA256", ChecksumValue: h})
+	}
+	if h := hexHash(meta.SHA1Sum); h != "" {
+		p.Checksums = append(p.Checksums, spdxChecksum{Algorithm: "SHA1", ChecksumValue: h})
+	}
+	if h := hexHash(meta.MD5Sum); h != "" {
+		p.Checksums = append(p.Checksums, spdxChecksum{Algorithm: "MD5", ChecksumValue: h})
+	}
+	if meta.MinimumOperatingSystemVersion != "" {
+		p.Comment = "LSMinimumSystemVersion: " + meta.MinimumOperatingSystemVersion
+	}
+	return p
+}
+
+func generateSPDX(meta *xar.PKGInstallerMetadata, components []*xar.PKGInstallerMetadata) ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              meta.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", meta.Name, hexHash(meta.SHA256Sum)),
+		Packages:          []spdxPackage{spdxPackageFor(meta, "SPDXRef-Package")},
+	}
+	for i, c := range components {
+		doc.Packages = append(doc.Packages, spdxPackageFor(c, fmt.Sprintf("SPDXRef-Package-%d", i+1)))
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
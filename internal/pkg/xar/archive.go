@@ -0,0 +1,182 @@
+package xar
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+// Reader provides general read access to a xar archive's table of contents,
+// independent of the Distribution/PackageInfo-specific parsing
+// ExtractXARMetadata does. It mirrors the split archive/zip uses between a
+// Reader (the TOC) and its File entries (openable substreams over the heap
+// region), so callers can pull individual signed binaries, plists, and
+// scripts out of a .pkg without shelling out to xar or Xcode.
+type Reader struct {
+	// File lists every entry in the archive's TOC, in the order the TOC
+	// declares them, with directories flattened out - Name carries the full
+	// path (e.g. "Scripts/postinstall").
+	File []*File
+
+	ra         io.ReaderAt
+	heapOffset int64
+	heapLength int64
+}
+
+// File is a single entry in a xar archive's TOC.
+type File struct {
+	// Name is the entry's full path within the archive.
+	Name string
+	// Size is the entry's uncompressed size in bytes.
+	Size int64
+	// Offset is the entry's offset within the xar heap region.
+	Offset int64
+	// Encoding is the compression style the TOC reports for this entry
+	// (e.g. "application/x-gzip"), or "" if the TOC didn't report one, in
+	// which case Open sniffs the entry's magic bytes instead.
+	Encoding string
+
+	raw        *xmlFile
+	ra         io.ReaderAt
+	heapOffset int64
+	heapLength int64
+}
+
+// OpenReader parses the xar archive in r, which has the given total size,
+// and returns a Reader exposing every file in its TOC.
+func OpenReader(r io.ReaderAt, size int64) (*Reader, error) {
+	headerBytes := make([]byte, xarHeaderSize)
+	if _, err := r.ReadAt(headerBytes, 0); err != nil {
+		return nil, fmt.Errorf("read xar header: %w", err)
+	}
+	hdr, _, err := parseHeader(bytes.NewReader(headerBytes))
+	if err != nil {
+		return nil, fmt.Errorf("decode xar header: %w", err)
+	}
+
+	tocSection := io.NewSectionReader(r, int64(hdr.HeaderSize), hdr.CompressedSize)
+	tocXML, err := decompress(tocSection)
+	if err != nil {
+		return nil, fmt.Errorf("decompress TOC: %w", err)
+	}
+
+	var root xmlXar
+	if err := xml.Unmarshal(tocXML, &root); err != nil {
+		return nil, fmt.Errorf("decode TOC XML: %w", err)
+	}
+
+	heapOffset := int64(hdr.HeaderSize) + hdr.CompressedSize
+	xr := &Reader{
+		ra:         r,
+		heapOffset: heapOffset,
+		heapLength: size - heapOffset,
+	}
+
+	var walk func(files []*xmlFile, prefix string)
+	walk = func(files []*xmlFile, prefix string) {
+		for _, f := range files {
+			if f == nil {
+				continue
+			}
+			name := f.Name
+			if prefix != "" {
+				name = prefix + "/" + name
+			}
+
+			file := &File{
+				Name:       name,
+				raw:        f,
+				ra:         r,
+				heapOffset: heapOffset,
+				heapLength: xr.heapLength,
+			}
+			if f.Data != nil {
+				file.Size = f.Data.Length
+				file.Offset = f.Data.Offset
+				file.Encoding = f.Data.Encoding.Style
+			}
+			xr.File = append(xr.File, file)
+
+			if len(f.Files) > 0 {
+				walk(f.Files, name)
+			}
+		}
+	}
+	walk(root.TOC.Files, "")
+
+	return xr, nil
+}
+
+// Payloads returns every entry in r named "Payload", the cpio+gzip archive
+// each component sub-package carries its installed files in.
+func (r *Reader) Payloads() []*File {
+	var out []*File
+	for _, f := range r.File {
+		if baseName(f.Name) == "Payload" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func baseName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// Open returns a reader over f's decompressed contents.
+func (f *File) Open() (io.ReadCloser, error) {
+	if f.raw.Data == nil {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	heapReader := io.NewSectionReader(f.ra, f.heapOffset, f.heapLength)
+	section := io.NewSectionReader(heapReader, f.raw.Data.Offset, f.raw.Data.Length)
+	return openEntryReader(section, f.raw)
+}
+
+// PayloadReader is a cpio.Reader over a Payload file's contents, plus the
+// resources backing it, released by Close.
+type PayloadReader struct {
+	*cpio.Reader
+	closer io.Closer
+}
+
+// Close releases the resources backing p. The embedded cpio.Reader itself
+// has no Close method.
+func (p *PayloadReader) Close() error {
+	return p.closer.Close()
+}
+
+// OpenPayload opens f, a "Payload" entry (see Reader.Payloads), as a cpio
+// archive so its individual files can be iterated without the caller
+// needing to know Payload's on-disk format. Payload entries are themselves
+// gzip-compressed cpio archives, on top of whatever compression the xar
+// heap itself applied (already undone by Open), so OpenPayload transparently
+// gunzips first if the decompressed bytes look gzip-encoded.
+func (f *File) OpenPayload() (*PayloadReader, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(rc)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("create gzip reader: %w", err)
+		}
+		return &PayloadReader{Reader: cpio.NewReader(gz), closer: rc}, nil
+	}
+
+	return &PayloadReader{Reader: cpio.NewReader(br), closer: rc}, nil
+}
@@ -8,18 +8,21 @@ import (
 	"io"
 )
 
-func parseTOC(r io.Reader, hashType crypto.Hash) (*toc, error) {
+// parseTOC decompresses and decodes the xar TOC read from r, returning the
+// parsed TOC along with the digest (per hashType) of the compressed TOC
+// bytes - the same digest xar signs when the package carries a signature.
+func parseTOC(r io.Reader, hashType crypto.Hash) (*toc, []byte, error) {
 	tocHash := hashType.New()
 	r = io.TeeReader(r, tocHash)
 	decomp, err := decompress(r)
 	if err != nil {
-		return nil, fmt.Errorf("decompressing TOC: %w", err)
+		return nil, nil, fmt.Errorf("decompressing TOC: %w", err)
 	}
 	var toc tocXar
 	if err := xml.Unmarshal(decomp, &toc); err != nil {
-		return nil, fmt.Errorf("decoding TOC: %w", err)
+		return nil, nil, fmt.Errorf("decoding TOC: %w", err)
 	}
-	return &toc.TOC, nil
+	return &toc.TOC, tocHash.Sum(nil), nil
 }
 
 func parseHeader(r io.Reader) (xarHeader, crypto.Hash, error) {
@@ -1,10 +1,119 @@
 package xar
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 )
 
+// ParseOptions configures ParseDistributionFile's defenses against
+// maliciously crafted Distribution XML, which is commonly downloaded from
+// untrusted mirrors and fed straight into this parser. The zero value
+// applies the default limits below.
+type ParseOptions struct {
+	// MaxDepth caps how many levels of nested elements are allowed. If
+	// zero, defaultMaxXMLDepth is used.
+	MaxDepth int
+	// MaxElements caps the total number of elements across the document.
+	// If zero, defaultMaxXMLElements is used.
+	MaxElements int
+	// MaxStringLength caps the length of any single attribute value or
+	// run of character data. If zero, defaultMaxXMLStringLength is used.
+	MaxStringLength int
+}
+
+const (
+	defaultMaxXMLDepth        = 1000
+	defaultMaxXMLElements     = 100_000
+	defaultMaxXMLStringLength = 1 << 20 // 1 MiB
+)
+
+func (o ParseOptions) maxDepth() int {
+	if o.MaxDepth == 0 {
+		return defaultMaxXMLDepth
+	}
+	return o.MaxDepth
+}
+
+func (o ParseOptions) maxElements() int {
+	if o.MaxElements == 0 {
+		return defaultMaxXMLElements
+	}
+	return o.MaxElements
+}
+
+func (o ParseOptions) maxStringLength() int {
+	if o.MaxStringLength == 0 {
+		return defaultMaxXMLStringLength
+	}
+	return o.MaxStringLength
+}
+
+var (
+	// ErrXMLTooDeep is returned by ParseDistributionFile when the document
+	// nests elements more deeply than ParseOptions.MaxDepth allows.
+	ErrXMLTooDeep = errors.New("distribution XML: element nesting too deep")
+	// ErrXMLTooLarge is returned by ParseDistributionFile when the document
+	// has more elements than ParseOptions.MaxElements, or an attribute
+	// value or character-data run longer than ParseOptions.MaxStringLength.
+	ErrXMLTooLarge = errors.New("distribution XML: exceeds configured size limits")
+	// errXMLHasDOCTYPE is returned by ParseDistributionFile when the
+	// document declares a DOCTYPE, which this parser never needs to honor
+	// and which Go's xml.Decoder would otherwise use to drive DTD-based
+	// entity expansion.
+	errXMLHasDOCTYPE = errors.New("distribution XML: DOCTYPE declarations are not allowed")
+)
+
+// limitingTokenReader sits between an *xml.Decoder reading the raw bytes
+// and the *xml.Decoder that unmarshals into distributionXML, enforcing
+// ParseOptions on every token before it reaches the struct decoder. This is
+// the streaming defense against the deeply-nested-element stack exhaustion
+// class of bugs: depth, element count, and string length are all checked
+// incrementally, without ever buffering the whole document tree.
+type limitingTokenReader struct {
+	dec   *xml.Decoder
+	opt   ParseOptions
+	depth int
+	elems int
+}
+
+func (l *limitingTokenReader) Token() (xml.Token, error) {
+	tok, err := l.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case xml.StartElement:
+		l.depth++
+		if l.depth > l.opt.maxDepth() {
+			return nil, ErrXMLTooDeep
+		}
+		l.elems++
+		if l.elems > l.opt.maxElements() {
+			return nil, ErrXMLTooLarge
+		}
+		for _, attr := range t.Attr {
+			if len(attr.Value) > l.opt.maxStringLength() {
+				return nil, ErrXMLTooLarge
+			}
+		}
+	case xml.EndElement:
+		l.depth--
+	case xml.CharData:
+		if len(t) > l.opt.maxStringLength() {
+			return nil, ErrXMLTooLarge
+		}
+	case xml.Directive:
+		if bytes.HasPrefix(bytes.TrimSpace(t), []byte("DOCTYPE")) {
+			return nil, errXMLHasDOCTYPE
+		}
+	}
+
+	return tok, nil
+}
+
 // distributionXML represents the structure of a Distribution file using XML tags.
 type distributionXML struct {
 	XMLName xml.Name `xml:"installer-gui-script"`
@@ -35,16 +144,33 @@ type distributionXML struct {
 	} `xml:"product"`
 }
 
-// parseDistributionFile decodes the distribution file using the XML parser and extracts:
+// ParseDistributionFile decodes the distribution file using a streaming XML
+// parser and extracts:
 // - Title (used for Name and DisplayName)
 // - Host Architectures
 // - Minimum OS Version
 // - Unique Package IDs (an index of all App Bundle IDs)
 // - For each pkg-ref with a bundle-version, every bundle with a CFBundleShortVersionString and id is added to AppBundles.
 // - Primary Bundle Identifier and Installation Path are set from the first pkg-ref whose bundle id matches its pkg-ref id.
-func parseDistributionFile(rawXML []byte) (*PKGInstallerMetadata, error) {
+//
+// Distribution files are commonly extracted from .pkg archives downloaded
+// from untrusted mirrors, so rawXML is decoded through limitingTokenReader
+// rather than xml.Unmarshal: opts bounds nesting depth, total element
+// count, and attribute/character-data length (see ErrXMLTooDeep,
+// ErrXMLTooLarge), DOCTYPE declarations are rejected outright, and entity
+// expansion is disabled by clearing xml.Decoder.Entity.
+func ParseDistributionFile(rawXML []byte, opts ParseOptions) (*PKGInstallerMetadata, error) {
+	base := xml.NewDecoder(bytes.NewReader(rawXML))
+	base.Strict = true
+	base.Entity = nil
+
+	dec := xml.NewTokenDecoder(&limitingTokenReader{dec: base, opt: opts})
+
 	var distXML distributionXML
-	if err := xml.Unmarshal(rawXML, &distXML); err != nil {
+	if err := dec.Decode(&distXML); err != nil {
+		if errors.Is(err, ErrXMLTooDeep) || errors.Is(err, ErrXMLTooLarge) || errors.Is(err, errXMLHasDOCTYPE) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("unmarshal Distribution XML: %w", err)
 	}
 
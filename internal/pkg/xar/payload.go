@@ -0,0 +1,75 @@
+package xar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+// enumeratePayloadFiles lists the files a package's Payload entry will
+// install, without extracting their contents. A package's Payload is a cpio
+// archive that is itself gzip-compressed (independently of the xar heap
+// compression already undone by readCompressedFile), so this transparently
+// gunzips data if it looks gzip-encoded.
+//
+// remainingBytes is decremented by each entry's size as it's read, and
+// enumeration stops once it is exhausted, as a guard against zip-bomb style
+// Payloads. That check alone isn't enough, though: cpio.Reader.Next discards
+// the previous entry's full declared Size via an unbounded io.CopyN before
+// this loop gets a chance to look at it, so a single entry claiming a huge
+// Size backed by a small, highly compressible body would decompress in full
+// regardless. r is therefore also wrapped in an io.LimitReader capped at the
+// budget, so the cpio reader physically can't pull more than that out of it
+// - at the cost of that discard then surfacing as a plain io.EOF indistinct
+// from a clean end of archive, which is handled below.
+func enumeratePayloadFiles(data []byte, remainingBytes *int64) ([]PayloadFile, error) {
+	r, err := openPayloadReader(data)
+	if err != nil {
+		return nil, fmt.Errorf("open payload: %w", err)
+	}
+
+	cr := cpio.NewReader(io.LimitReader(r, *remainingBytes))
+	var files []PayloadFile
+	for {
+		hdr, err := cr.Next()
+		if err == io.EOF {
+			// cpio.Reader.Next discards the previous entry's remainder before
+			// reporting EOF, so if our LimitReader ran dry partway through
+			// that discard, this is the same io.EOF a clean end of archive
+			// would produce. Tell them apart by checking whether the budget
+			// was already blown by the last entry we accepted.
+			if *remainingBytes < 0 {
+				return files, fmt.Errorf("payload exceeds decompression budget after %d entries", len(files))
+			}
+			break
+		}
+		if err != nil {
+			return files, fmt.Errorf("read payload entry: %w", err)
+		}
+
+		if *remainingBytes <= 0 {
+			return files, fmt.Errorf("payload exceeds decompression budget after %d entries", len(files))
+		}
+		*remainingBytes -= hdr.Size
+
+		files = append(files, PayloadFile{
+			Name: hdr.Name,
+			Mode: uint32(hdr.Mode),
+			Size: hdr.Size,
+		})
+	}
+
+	return files, nil
+}
+
+// openPayloadReader wraps data with a gzip reader if it looks gzip-encoded,
+// otherwise it returns data as-is.
+func openPayloadReader(data []byte) (io.Reader, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		return gzip.NewReader(bytes.NewReader(data))
+	}
+	return bytes.NewReader(data), nil
+}
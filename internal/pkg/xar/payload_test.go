@@ -0,0 +1,44 @@
+package xar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+// TestEnumeratePayloadFilesEnforcesSkipBudget verifies that a cpio entry
+// declaring a large Size, backed by a small, highly compressible gzip
+// stream, can't be fully decompressed just by cpio.Reader.Next discarding
+// it to get to the next entry - the remaining decompression budget should
+// cap that discard too, not just the post-hoc check in the loop body.
+func TestEnumeratePayloadFilesEnforcesSkipBudget(t *testing.T) {
+	const bigSize = 8 << 20 // 8 MiB of zeroes - decompresses to almost nothing.
+
+	var cpioBuf bytes.Buffer
+	cw := cpio.NewWriter(&cpioBuf)
+	if err := cw.WriteHeader(&cpio.Header{Name: "bigfile", Size: bigSize, Mode: cpio.ModePerm | cpio.TypeReg}); err != nil {
+		t.Fatalf("write cpio header: %v", err)
+	}
+	if _, err := cw.Write(make([]byte, bigSize)); err != nil {
+		t.Fatalf("write cpio body: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("close cpio writer: %v", err)
+	}
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	if _, err := gz.Write(cpioBuf.Bytes()); err != nil {
+		t.Fatalf("write gzip payload: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip writer: %v", err)
+	}
+
+	budget := int64(4096)
+	if _, err := enumeratePayloadFiles(gzBuf.Bytes(), &budget); err == nil {
+		t.Fatal("enumeratePayloadFiles did not enforce the decompression budget while skipping an oversized entry")
+	}
+}
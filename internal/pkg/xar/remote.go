@@ -0,0 +1,220 @@
+package xar
+
+import (
+	"context"
+	"crypto"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	hash "github.com/deploymenttheory/go-installer-tools/internal/crypto"
+	"github.com/deploymenttheory/go-installer-tools/internal/logger"
+	"github.com/deploymenttheory/go-installer-tools/internal/reader"
+)
+
+// ExtractXARMetadataFromURL extracts a .pkg's metadata from url over
+// HTTP(S), without downloading the whole file when the server supports
+// range requests: it fetches just the xar header (28 bytes), the TOC (its
+// compressed size is given by the header), and then only the compressed
+// Distribution/PackageInfo segments the TOC points to in the heap. This
+// deliberately skips the Payload and any embedded component packages, which
+// are typically far larger and unnecessary just to identify a package -
+// callers that need that level of detail should download the package (e.g.
+// via the fallback path below) and use ExtractXARMetadata instead.
+//
+// Digests are not computed in this mode unless ExtractOptions.ComputeHashes
+// is set, since that requires a second, full download of the package.
+//
+// When the server doesn't support range requests, the first request's
+// response is used as a full download and metadata is extracted from it the
+// same way ExtractXARMetadata would, digests included.
+func ExtractXARMetadataFromURL(ctx context.Context, url string, opts ...ExtractOptions) (*PKGInstallerMetadata, error) {
+	var opt ExtractOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	client := opt.httpClient()
+
+	headerResp, err := rangeGet(ctx, client, url, 0, xarHeaderSize)
+	if err != nil {
+		return nil, fmt.Errorf("request xar header: %w", err)
+	}
+	defer headerResp.Body.Close()
+
+	if headerResp.StatusCode != http.StatusPartialContent {
+		logger.Debug("Server does not support range requests, falling back to full download",
+			"url", url, "status", headerResp.StatusCode)
+		return extractXARMetadataFromStream(headerResp.Body, opt)
+	}
+
+	hdr, _, err := parseHeader(headerResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decode xar header: %w", err)
+	}
+
+	tocResp, err := rangeGet(ctx, client, url, int64(hdr.HeaderSize), hdr.CompressedSize)
+	if err != nil {
+		return nil, fmt.Errorf("request xar TOC: %w", err)
+	}
+	defer tocResp.Body.Close()
+	if tocResp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("server stopped honoring range requests mid-fetch (status %s)", tocResp.Status)
+	}
+
+	tocXML, err := decompress(tocResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decompress TOC: %w", err)
+	}
+
+	var xroot xmlXar
+	if err := xml.Unmarshal(tocXML, &xroot); err != nil {
+		return nil, fmt.Errorf("decode TOC XML: %w", err)
+	}
+
+	// A second, independent decode of the same bytes into the
+	// signature-only TOC shape, just to report whether the package is
+	// signed; see CheckPKGSignature for why these are separate types.
+	var sroot tocXar
+	_ = xml.Unmarshal(tocXML, &sroot)
+	isSigned := sroot.TOC.Signature != nil || sroot.TOC.XSignature != nil
+
+	heapOffset := int64(hdr.HeaderSize) + hdr.CompressedSize
+
+	var distributionContents, packageInfoContents []byte
+	remainingBytes := opt.maxComponentBytes()
+	walkFiles(xroot.TOC.Files, func(f *xmlFile) {
+		if f.Data == nil || (f.Name != "Distribution" && f.Name != "PackageInfo") {
+			return
+		}
+		if remainingBytes <= 0 {
+			logger.Warn("Decompression budget exhausted, skipping remaining entries", "name", f.Name)
+			return
+		}
+
+		entryResp, err := rangeGet(ctx, client, url, heapOffset+f.Data.Offset, f.Data.Length)
+		if err != nil {
+			logger.Error("Failed to fetch TOC entry", "name", f.Name, "error", err)
+			return
+		}
+		defer entryResp.Body.Close()
+
+		contents, err := decompressEntry(entryResp.Body, f, remainingBytes)
+		if err != nil {
+			logger.Error("Failed to decompress TOC entry", "name", f.Name, "error", err)
+			return
+		}
+		remainingBytes -= int64(len(contents))
+
+		switch f.Name {
+		case "Distribution":
+			distributionContents = contents
+		case "PackageInfo":
+			packageInfoContents = contents
+		}
+	})
+
+	var meta *PKGInstallerMetadata
+	switch {
+	case distributionContents != nil:
+		meta, err = ParseDistributionFile(distributionContents, ParseOptions{})
+	case packageInfoContents != nil:
+		meta, err = parsePackageInfoFile(packageInfoContents)
+	default:
+		meta = &PKGInstallerMetadata{}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse metadata: %w", err)
+	}
+	meta.IsSigned = isSigned
+
+	if opt.ComputeHashes {
+		sums, size, err := hashURL(ctx, client, url, opt.algorithms())
+		if err != nil {
+			logger.Error("Failed to compute digests", "url", url, "error", err)
+		} else {
+			meta.SHA256Sum = sums[crypto.SHA256]
+			meta.SHA1Sum = sums[crypto.SHA1]
+			meta.MD5Sum = sums[crypto.MD5]
+			meta.SHA512Sum = sums[crypto.SHA512]
+			meta.BLAKE2b256Sum = sums[crypto.BLAKE2b_256]
+			meta.PkgSizeMB = float64(size) / (1024 * 1024)
+		}
+	}
+
+	return meta, nil
+}
+
+// extractXARMetadataFromStream spills r (typically a full HTTP response
+// body) to a temporary file and runs it through the same recursive
+// extraction ExtractXARMetadata uses, so the fallback path gets components,
+// payload enumeration, digests, and signature verification for free, since
+// it already paid for the whole download. The body is copied to a temp file
+// up front and that file is wrapped in a TempFileReader, so the several
+// passes extractXARMetadata makes over the package (hashing, signature
+// check, TOC decode, ...) read from disk rather than from the HTTP
+// response body.
+func extractXARMetadataFromStream(r io.Reader, opt ExtractOptions) (*PKGInstallerMetadata, error) {
+	tmp, err := os.CreateTemp("", "xar-remote-*.pkg")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		return nil, fmt.Errorf("download package: %w", err)
+	}
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("rewind temp file: %w", err)
+	}
+
+	tfr := reader.NewTempFileReader(tmp, reader.Options{})
+	defer tfr.Close()
+	remainingBytes := opt.maxComponentBytes()
+	return extractXARMetadata(tfr, opt, 0, &remainingBytes)
+}
+
+// rangeGet issues a GET for url requesting the byte range
+// [offset, offset+length), returning the response whether or not the server
+// honored the range - callers distinguish support by checking
+// StatusPartialContent. The caller must close the response body.
+func rangeGet(ctx context.Context, client *http.Client, url string, offset, length int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+	return resp, nil
+}
+
+// hashURL downloads url in full, computing every digest in algorithms in a
+// single pass.
+func hashURL(ctx context.Context, client *http.Client, url string, algorithms []crypto.Hash) (map[crypto.Hash][]byte, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	multiHasher := hash.NewMultiHasher(algorithms...)
+	size, err := io.Copy(multiHasher, resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return multiHasher.Sums(), size, nil
+}
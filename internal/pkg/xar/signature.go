@@ -0,0 +1,174 @@
+package xar
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.mozilla.org/pkcs7"
+
+	"github.com/deploymenttheory/go-installer-tools/internal/logger"
+)
+
+// SignatureInfo describes a verified xar/CMS signature.
+type SignatureInfo struct {
+	// SignerCN is the common name of the end-entity signing certificate.
+	SignerCN string
+	// SignatureAlgorithm names the digest algorithm the TOC checksum (and
+	// therefore the signature) was computed with, e.g. "SHA-256".
+	SignatureAlgorithm string
+	// Certificates holds the raw DER bytes of every certificate embedded in
+	// the signature, in the order xar stored them.
+	Certificates [][]byte
+	// NotBefore and NotAfter are the validity window of the end-entity
+	// signing certificate.
+	NotBefore time.Time
+	NotAfter  time.Time
+	// SigningTime is the CMS signingTime authenticated attribute, if the
+	// signer included one.
+	SigningTime time.Time
+	// ChainTrusted reports whether the certificate chain was validated
+	// against VerifyOptions.Roots. It is false when no roots were supplied,
+	// in which case only the signature's cryptographic validity - not its
+	// trust - has been checked.
+	ChainTrusted bool
+	// TeamID is the signer's Apple Developer Team ID, taken from the
+	// signing certificate's Subject.OrganizationalUnit, which is where
+	// Apple's "Developer ID Installer" certificates encode it. Empty if the
+	// signing certificate didn't carry one.
+	TeamID string
+	// NotarizationTicketStapled reports whether the package carries a
+	// stapled notarization ticket (a "com.apple.cms" file in the xar TOC).
+	// Only populated by VerifySignature, which can see the TOC's file
+	// listing; always false from VerifyPKGSignature.
+	NotarizationTicketStapled bool
+}
+
+// VerifyOptions configures VerifyPKGSignature and VerifySignature.
+type VerifyOptions struct {
+	// Roots, if non-nil, is used to validate the signing certificate's
+	// chain of trust (e.g. Apple's Installer Package Signing or Developer
+	// ID roots). Without it, verification still checks that the signature
+	// matches the TOC digest, but does not vouch for the signer's identity.
+	//
+	// This package doesn't bundle Apple's roots itself - doing so requires
+	// pinning an authentic, current copy of the PEM data Apple publishes at
+	// https://www.apple.com/certificateauthority/, which isn't something
+	// to embed without a way to verify it came from Apple. Callers that
+	// need chain-of-trust validation should load those roots themselves
+	// (e.g. with RootsFromPEMFile, pointed at a vendored copy of Apple's
+	// bundle) into the pool passed here, or use SystemRoots for a weaker
+	// but always-available option.
+	Roots *x509.CertPool
+}
+
+// SystemRoots returns the host's system certificate pool, suitable for
+// VerifyOptions.Roots when a caller wants some chain validation rather than
+// none. It is not a substitute for Apple's own Installer/Developer ID
+// roots specifically: on Windows and most Linux distributions the system
+// pool won't include them at all, and even where it does (macOS), it also
+// trusts every other root the OS does, which is broader than "was this
+// signed by Apple" calls for. Callers that need to check specifically
+// against Apple's roots should use RootsFromPEMFile with a vendored copy
+// instead.
+func SystemRoots() (*x509.CertPool, error) {
+	return x509.SystemCertPool()
+}
+
+// RootsFromPEMFile reads a PEM bundle from path and returns it as a
+// CertPool suitable for VerifyOptions.Roots - the mechanism by which a
+// caller supplies Apple's Installer/Developer ID roots (downloaded and
+// pinned separately; see VerifyOptions.Roots) rather than the broader
+// SystemRoots pool.
+func RootsFromPEMFile(path string) (*x509.CertPool, error) {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read roots file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// VerifyPKGSignature validates the CMS/PKCS#7 signature embedded in a xar
+// package's TOC against the TOC's own checksum, and optionally against a
+// trusted certificate chain.
+//
+// It returns ErrNotSigned if the package has no <signature>/<x-signature>
+// element, and ErrInvalidType if r is not a xar file.
+func VerifyPKGSignature(r io.ReaderAt, opts ...VerifyOptions) (*SignatureInfo, error) {
+	var opt VerifyOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	hdr, hashType, err := parseHeader(io.NewSectionReader(r, 0, xarHeaderSize))
+	if err != nil {
+		return nil, fmt.Errorf("decode xar header: %w", err)
+	}
+
+	t, tocDigest, err := parseTOC(io.NewSectionReader(r, int64(hdr.HeaderSize), hdr.CompressedSize), hashType)
+	if err != nil {
+		return nil, fmt.Errorf("parse TOC: %w", err)
+	}
+
+	sig := t.Signature
+	if sig == nil {
+		sig = t.XSignature
+	}
+	if sig == nil {
+		return nil, ErrNotSigned
+	}
+
+	heapOffset := int64(hdr.HeaderSize) + hdr.CompressedSize
+	blob := make([]byte, sig.Size)
+	if _, err := r.ReadAt(blob, heapOffset+sig.Offset); err != nil {
+		return nil, fmt.Errorf("read signature blob: %w", err)
+	}
+
+	p7, err := pkcs7.Parse(blob)
+	if err != nil {
+		return nil, fmt.Errorf("parse CMS signature: %w", err)
+	}
+	// xar signs the raw TOC digest, not an embedded copy of it, so the CMS
+	// content is detached - point it at the digest we just recomputed.
+	p7.Content = tocDigest
+
+	var chainTrusted bool
+	if opt.Roots != nil {
+		if err := p7.VerifyWithChain(opt.Roots); err != nil {
+			return nil, fmt.Errorf("verify signature chain: %w", err)
+		}
+		chainTrusted = true
+	} else if err := p7.Verify(); err != nil {
+		return nil, fmt.Errorf("verify signature: %w", err)
+	}
+
+	info := &SignatureInfo{
+		SignatureAlgorithm: hashType.String(),
+		ChainTrusted:       chainTrusted,
+	}
+	for _, cert := range p7.Certificates {
+		info.Certificates = append(info.Certificates, cert.Raw)
+	}
+	if signer := p7.GetOnlySigner(); signer != nil {
+		info.SignerCN = signer.Subject.CommonName
+		info.NotBefore = signer.NotBefore
+		info.NotAfter = signer.NotAfter
+		if len(signer.Subject.OrganizationalUnit) > 0 {
+			info.TeamID = signer.Subject.OrganizationalUnit[0]
+		}
+	}
+	var signingTime time.Time
+	if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &signingTime); err == nil {
+		info.SigningTime = signingTime
+	} else {
+		logger.Debug("No signingTime attribute present", "error", err)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,69 @@
+package xar
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestSystemRoots verifies SystemRoots returns a usable pool (or an
+// explicit error) rather than silently leaving VerifyOptions.Roots nil,
+// which would otherwise look identical to "chain validation not
+// requested".
+func TestSystemRoots(t *testing.T) {
+	roots, err := SystemRoots()
+	if err != nil {
+		t.Skipf("system cert pool unavailable in this environment: %v", err)
+	}
+	if roots == nil {
+		t.Fatal("SystemRoots returned a nil pool with no error")
+	}
+}
+
+// TestRootsFromPEMFile verifies a caller can load a vendored root bundle
+// (e.g. Apple's published Installer/Developer ID roots) from disk, rather
+// than being limited to SystemRoots.
+func TestRootsFromPEMFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "roots.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(path, pemBytes, 0o644); err != nil {
+		t.Fatalf("write roots file: %v", err)
+	}
+
+	pool, err := RootsFromPEMFile(path)
+	if err != nil {
+		t.Fatalf("RootsFromPEMFile: %v", err)
+	}
+	if pool == nil {
+		t.Fatal("RootsFromPEMFile returned a nil pool with no error")
+	}
+
+	if _, err := RootsFromPEMFile(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatal("RootsFromPEMFile did not error on a missing file")
+	}
+}
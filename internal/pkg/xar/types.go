@@ -0,0 +1,84 @@
+package xar
+
+// AppBundle describes a single application bundle referenced by a pkg-ref
+// in a Distribution file (or, for flat packages, by a PackageInfo bundle
+// entry).
+type AppBundle struct {
+	// ID is the bundle's CFBundleIdentifier.
+	ID string
+	// ShortVersion is the bundle's CFBundleShortVersionString.
+	ShortVersion string
+	// AppLocationPath is the path the bundle is installed to, relative to
+	// the pkg's install location.
+	AppLocationPath string
+}
+
+// PKGInstallerMetadata holds the metadata extracted from a .pkg installer,
+// whether sourced from a Distribution file (product archives) or a
+// PackageInfo file (flat/component packages).
+type PKGInstallerMetadata struct {
+	// Name is the package's short display name.
+	Name string
+	// ApplicationTitle is the title reported by the Distribution/PackageInfo
+	// file; for most packages this is the same value as Name.
+	ApplicationTitle string
+	// DisplayName is the human-readable name shown to the user during
+	// installation.
+	DisplayName string
+	// BundleName is the CFBundleName of the primary app bundle, if any.
+	BundleName string
+	// Version is the overall package version.
+	Version string
+	// PrimaryBundleIdentifier is the bundle ID of the package's main
+	// application.
+	PrimaryBundleIdentifier string
+	// PackageIDs is the set of all bundle identifiers referenced by the
+	// package.
+	PackageIDs []string
+	// MinimumOperatingSystemVersion is the lowest macOS version the package
+	// declares support for.
+	MinimumOperatingSystemVersion string
+	// HostArchitectures lists the CPU architectures the package supports.
+	HostArchitectures string
+	// PrimaryBundlePath is the install path of the package's main
+	// application bundle.
+	PrimaryBundlePath string
+	// PkgSizeMB is the size of the .pkg file itself, in megabytes.
+	PkgSizeMB float64
+	// SHA256Sum, SHA1Sum, MD5Sum, SHA512Sum, and BLAKE2b256Sum are digests of
+	// the whole .pkg file. Only the digests requested via ExtractOptions are
+	// populated.
+	SHA256Sum     []byte
+	SHA1Sum       []byte
+	MD5Sum        []byte
+	SHA512Sum     []byte
+	BLAKE2b256Sum []byte
+	// IsSigned reports whether the package carries a xar signature.
+	IsSigned bool
+	// SignerCN, SignatureAlgorithm, and Certificates describe the package's
+	// signature when IsSigned is true and verification succeeded; see
+	// VerifyPKGSignature.
+	SignerCN           string
+	SignatureAlgorithm string
+	Certificates       [][]byte
+	// AppBundles lists every application bundle referenced by the package.
+	AppBundles []AppBundle
+	// Components lists embedded component .pkg archives found inside this
+	// package's XAR TOC (e.g. under Contents/Packages in a distribution
+	// package), recursively parsed up to ExtractOptions.MaxComponentDepth.
+	Components []*PKGInstallerMetadata
+	// Files lists the paths this package's Payload will install, populated
+	// when the package carries a Payload entry and it can be enumerated.
+	Files []PayloadFile
+}
+
+// PayloadFile describes a single entry inside a package's cpio Payload
+// archive.
+type PayloadFile struct {
+	// Name is the file's path, relative to the package's install location.
+	Name string
+	// Mode is the entry's cpio file mode (permissions plus type bits).
+	Mode uint32
+	// Size is the entry's uncompressed size in bytes.
+	Size int64
+}
@@ -0,0 +1,102 @@
+package xar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDistributionNotFound is returned by VerifyAndParseDistribution when the
+// archive has no Distribution file to parse.
+var ErrDistributionNotFound = errors.New("no Distribution file found")
+
+// VerifySignature validates r's embedded CMS/PKCS#7 signature the same way
+// VerifyPKGSignature does, and additionally reports whether the archive
+// carries a stapled notarization ticket - a "com.apple.cms" file deposited
+// in the xar TOC by `xcrun stapler staple` - which VerifyPKGSignature can't
+// see on its own since it only has the raw archive bytes, not its parsed
+// file listing.
+//
+// It returns ErrNotSigned if the package has no <signature>/<x-signature>
+// element.
+func VerifySignature(r *Reader, opts VerifyOptions) (*SignatureInfo, error) {
+	info, err := VerifyPKGSignature(r.ra, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range r.File {
+		if baseName(f.Name) == "com.apple.cms" {
+			info.NotarizationTicketStapled = true
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// VerifiedMetadata pairs metadata extracted from a .pkg's Distribution file
+// with the outcome of verifying the package's signature, so callers can
+// require a trusted signature before trusting the extracted bundle IDs.
+// Signature is nil whenever verification did not succeed (the package is
+// unsigned, its signature failed to verify, or verification could not
+// run); the error VerifyAndParseDistribution returns says which. If the
+// archive can't be opened at all, no VerifiedMetadata is returned.
+// PKGInstallerMetadata is nil if no Distribution file was found
+// or it failed to parse.
+type VerifiedMetadata struct {
+	*PKGInstallerMetadata
+	Signature *SignatureInfo
+}
+
+// VerifyAndParseDistribution opens the xar archive at ra, which must span
+// exactly size bytes, verifies its signature, and parses its Distribution
+// file, returning both together in a VerifiedMetadata. The signature
+// verification outcome (including ErrNotSigned, if the package isn't
+// signed) is always folded into the returned error via errors.Join, even
+// when Distribution parsing itself also fails, so callers can't
+// accidentally trust PKGInstallerMetadata - or miss a failed signature
+// check behind an unrelated parse error - without checking it. Use
+// errors.Is/errors.As to pull a specific error back out of the result.
+func VerifyAndParseDistribution(ra io.ReaderAt, size int64, opts VerifyOptions, parseOpts ParseOptions) (*VerifiedMetadata, error) {
+	xr, err := OpenReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("open xar archive: %w", err)
+	}
+
+	info, sigErr := VerifySignature(xr, opts)
+	vm := &VerifiedMetadata{Signature: info}
+
+	var dist *File
+	for _, f := range xr.File {
+		if baseName(f.Name) == "Distribution" {
+			dist = f
+			break
+		}
+	}
+	if dist == nil {
+		if sigErr != nil {
+			return vm, errors.Join(sigErr, ErrDistributionNotFound)
+		}
+		return vm, ErrDistributionNotFound
+	}
+
+	rc, err := dist.Open()
+	if err != nil {
+		return vm, errors.Join(sigErr, fmt.Errorf("open Distribution: %w", err))
+	}
+	defer rc.Close()
+
+	raw, err := io.ReadAll(rc)
+	if err != nil {
+		return vm, errors.Join(sigErr, fmt.Errorf("read Distribution: %w", err))
+	}
+
+	meta, err := ParseDistributionFile(raw, parseOpts)
+	if err != nil {
+		return vm, errors.Join(sigErr, fmt.Errorf("parse Distribution: %w", err))
+	}
+	vm.PKGInstallerMetadata = meta
+
+	return vm, sigErr
+}
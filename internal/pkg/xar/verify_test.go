@@ -0,0 +1,66 @@
+package xar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// buildTestXar assembles a minimal, unsigned xar archive with a single
+// uncompressed heap entry named name holding contents, for exercising TOC
+// parsing without needing a real .pkg fixture.
+func buildTestXar(t *testing.T, name string, contents []byte) []byte {
+	t.Helper()
+
+	size := strconv.Itoa(len(contents))
+	tocXML := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xar><toc><file><name>` + name + `</name><type>file</type><data><length>` +
+		size + `</length><offset>0</offset><size>` + size + `</size></data></file></toc></xar>`)
+
+	var tocBuf bytes.Buffer
+	zw := zlib.NewWriter(&tocBuf)
+	if _, err := zw.Write(tocXML); err != nil {
+		t.Fatalf("compress TOC: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close TOC writer: %v", err)
+	}
+
+	hdr := xarHeader{
+		Magic:            xarMagic,
+		HeaderSize:       xarHeaderSize,
+		Version:          1,
+		CompressedSize:   int64(tocBuf.Len()),
+		UncompressedSize: int64(len(tocXML)),
+		HashType:         hashSHA256,
+	}
+
+	var out bytes.Buffer
+	if err := binary.Write(&out, binary.BigEndian, hdr); err != nil {
+		t.Fatalf("write xar header: %v", err)
+	}
+	out.Write(tocBuf.Bytes())
+	out.Write(contents)
+	return out.Bytes()
+}
+
+// TestVerifyAndParseDistributionJoinsSignatureError verifies that a
+// Distribution parse failure doesn't hide an earlier signature verification
+// failure, per VerifyAndParseDistribution's doc comment.
+func TestVerifyAndParseDistributionJoinsSignatureError(t *testing.T) {
+	pkg := buildTestXar(t, "Distribution", []byte("not valid xml <<<"))
+
+	vm, err := VerifyAndParseDistribution(bytes.NewReader(pkg), int64(len(pkg)), VerifyOptions{}, ParseOptions{})
+	if err == nil {
+		t.Fatal("expected an error from an unsigned package with an unparsable Distribution")
+	}
+	if !errors.Is(err, ErrNotSigned) {
+		t.Errorf("error %v does not wrap ErrNotSigned", err)
+	}
+	if vm.Signature != nil {
+		t.Errorf("Signature = %+v, want nil for an unsigned package", vm.Signature)
+	}
+}
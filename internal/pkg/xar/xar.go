@@ -19,15 +19,16 @@ package xar
 // https://github.com/sassoftware/relic
 
 import (
+	"bufio"
 	"bytes"
-	"compress/bzip2"
 	"compress/zlib"
-	"crypto/sha256"
+	"crypto"
 	"encoding/binary"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
 	hash "github.com/deploymenttheory/go-installer-tools/internal/crypto"
@@ -75,8 +76,22 @@ type tocXar struct {
 }
 
 type toc struct {
-	Signature  *any `xml:"signature"`
-	XSignature *any `xml:"x-signature"`
+	Signature  *xmlSignature `xml:"signature"`
+	XSignature *xmlSignature `xml:"x-signature"`
+}
+
+// xmlSignature represents a xar <signature>/<x-signature> TOC element: the
+// location of the CMS/PKCS#7 blob in the heap, plus the certificate chain
+// used to produce it.
+type xmlSignature struct {
+	Style   string `xml:"style,attr"`
+	Offset  int64  `xml:"offset"`
+	Size    int64  `xml:"size"`
+	KeyInfo struct {
+		X509Data struct {
+			X509Certificate []string `xml:"X509Certificate"`
+		} `xml:"X509Data"`
+	} `xml:"KeyInfo"`
 }
 
 type xmlXar struct {
@@ -104,21 +119,126 @@ type xmlFile struct {
 	Name    string   `xml:"name"`
 	Type    string   `xml:"type"`
 	Data    *xmlFileData
+	// Files holds this entry's children when Type is "directory".
+	Files []*xmlFile `xml:"file"`
+}
+
+// walkFiles calls fn for every entry in files, recursing into directories.
+func walkFiles(files []*xmlFile, fn func(f *xmlFile)) {
+	for _, f := range files {
+		if f == nil {
+			continue
+		}
+		fn(f)
+		if len(f.Files) > 0 {
+			walkFiles(f.Files, fn)
+		}
+	}
+}
+
+// ExtractOptions configures which digests ExtractXARMetadata computes over
+// the .pkg contents. The zero value computes the historical default set
+// (SHA256, SHA1, MD5).
+type ExtractOptions struct {
+	// Algorithms selects which digests to compute. If empty, SHA256, SHA1,
+	// and MD5 are computed.
+	Algorithms []crypto.Hash
+	// Verify holds the VerifyOptions (e.g. Roots) used for the full
+	// signature verification (see VerifyPKGSignature) attempted on every
+	// signed package, which populates SignerCN, SignatureAlgorithm, and
+	// Certificates on the returned metadata. If nil, verification runs with
+	// zero VerifyOptions, so the certificate chain is not validated.
+	Verify *VerifyOptions
+	// MaxComponentDepth caps how many levels of embedded component .pkg
+	// archives are recursed into. If zero, defaultMaxComponentDepth is
+	// used.
+	MaxComponentDepth int
+	// MaxComponentBytes caps the total number of decompressed bytes read out
+	// of heap entries (metadata files, Payloads, and embedded component
+	// packages, at every level), shared across the whole recursion tree, to
+	// guard against zip-bomb style inputs. If
+	// zero, defaultMaxComponentBytes is used.
+	MaxComponentBytes int64
+	// HTTPClient is used by ExtractXARMetadataFromURL to issue requests. If
+	// nil, http.DefaultClient is used. Ignored by ExtractXARMetadata.
+	HTTPClient *http.Client
+	// ComputeHashes requests that ExtractXARMetadataFromURL compute digests
+	// (see Algorithms) by downloading the whole package in a second pass,
+	// after it has fetched only the metadata over range requests. Ignored by
+	// ExtractXARMetadata, which always has the whole file and always
+	// computes digests. Ignored when the server doesn't support range
+	// requests, since the whole-file fallback download already computes
+	// them.
+	ComputeHashes bool
+}
+
+const (
+	defaultMaxComponentDepth = 5
+	defaultMaxComponentBytes = 1 << 30 // 1 GiB
+)
+
+func (o ExtractOptions) algorithms() []crypto.Hash {
+	if len(o.Algorithms) == 0 {
+		return []crypto.Hash{crypto.SHA256, crypto.SHA1, crypto.MD5}
+	}
+	return o.Algorithms
+}
+
+func (o ExtractOptions) maxComponentDepth() int {
+	if o.MaxComponentDepth == 0 {
+		return defaultMaxComponentDepth
+	}
+	return o.MaxComponentDepth
+}
+
+func (o ExtractOptions) maxComponentBytes() int64 {
+	if o.MaxComponentBytes == 0 {
+		return defaultMaxComponentBytes
+	}
+	return o.MaxComponentBytes
+}
+
+func (o ExtractOptions) httpClient() *http.Client {
+	if o.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return o.HTTPClient
+}
+
+// ExtractXARMetadata extracts the name and version metadata from a .pkg
+// file in the XAR format, recursing into any embedded component .pkg
+// archives (see PKGInstallerMetadata.Components) up to
+// ExtractOptions.MaxComponentDepth and ExtractOptions.MaxComponentBytes.
+//
+// All requested digests (see ExtractOptions) are computed in a single pass
+// over tfr via a hash.MultiHasher, rather than one Rewind+io.Copy per
+// algorithm.
+func ExtractXARMetadata(tfr *reader.TempFileReader, opts ...ExtractOptions) (*PKGInstallerMetadata, error) {
+	var opt ExtractOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	remainingBytes := opt.maxComponentBytes()
+	return extractXARMetadata(tfr, opt, 0, &remainingBytes)
 }
 
-// ExtractXARMetadata extracts the name and version metadata from a .pkg file
-// in the XAR format. This version skips processing embedded packages.
-func ExtractXARMetadata(tfr *reader.TempFileReader) (*PKGInstallerMetadata, error) {
-	logger.Debug("Starting XAR metadata extraction")
+// extractXARMetadata is the recursive implementation behind
+// ExtractXARMetadata. depth counts how many component archives deep the
+// current call is, and remainingBytes is a budget shared across the whole
+// recursion tree.
+func extractXARMetadata(tfr *reader.TempFileReader, opt ExtractOptions, depth int, remainingBytes *int64) (*PKGInstallerMetadata, error) {
+	logger.Debug("Starting XAR metadata extraction", "depth", depth)
 
 	var meta *PKGInstallerMetadata
 	var isSignedStatus bool
 
-	// Compute SHA256 hash and capture total file size
-	sha256Hash := sha256.New()
-	size, _ := io.Copy(sha256Hash, tfr)
-	logger.Debug("Calculated initial hash and size", "size", size)
+	// Compute every requested digest and capture total file size in a
+	// single pass.
+	multiHasher := hash.NewMultiHasher(opt.algorithms()...)
+	size, _ := io.Copy(multiHasher, tfr)
+	logger.Debug("Calculated initial hashes and size", "size", size)
 	pkgSizeMB := float64(size) / (1024 * 1024)
+	sums := multiHasher.Sums()
 
 	// Check for package signature
 	if err := tfr.Rewind(); err != nil {
@@ -127,10 +247,26 @@ func ExtractXARMetadata(tfr *reader.TempFileReader) (*PKGInstallerMetadata, erro
 	}
 
 	// Check signature status
+	var sigInfo *SignatureInfo
 	err := CheckPKGSignature(tfr)
 	if err == nil {
 		isSignedStatus = true
 		logger.Debug("Package is signed")
+
+		if err := tfr.Rewind(); err != nil {
+			logger.Error("Failed to rewind reader for signature verification", "error", err)
+		} else {
+			var verifyOpt VerifyOptions
+			if opt.Verify != nil {
+				verifyOpt = *opt.Verify
+			}
+			info, err := VerifyPKGSignature(tfr, verifyOpt)
+			if err != nil {
+				logger.Debug("Signature present but verification failed", "error", err)
+			} else {
+				sigInfo = info
+			}
+		}
 	} else if err == ErrNotSigned {
 		isSignedStatus = false
 		logger.Debug("Package is not signed")
@@ -179,32 +315,50 @@ func ExtractXARMetadata(tfr *reader.TempFileReader) (*PKGInstallerMetadata, erro
 	// Variables to hold raw metadata file contents
 	var distributionContents []byte
 	var packageInfoContents []byte
+	var payloadContents []byte
+	type componentFile struct {
+		name     string
+		contents []byte
+	}
+	var componentFiles []componentFile
 
-	// Loop through TOC entries and collect metadata file contents
-	for _, f := range root.TOC.Files {
-		if f == nil || f.Data == nil {
-			continue
+	// Walk TOC entries (recursing into directories) and collect metadata
+	// file contents, the Payload, and any nested component .pkg archives.
+	walkFiles(root.TOC.Files, func(f *xmlFile) {
+		if f.Data == nil {
+			return
+		}
+		if *remainingBytes <= 0 {
+			logger.Warn("Decompression budget exhausted, skipping remaining entries", "name", f.Name)
+			return
 		}
 		logger.Debug("Examining metadata file", "name", f.Name, "offset", f.Data.Offset, "length", f.Data.Length)
-		contents, err := readCompressedFile(tfr, heapOffset, size, f)
+		contents, err := readCompressedFile(tfr, heapOffset, size, f, *remainingBytes)
 		if err != nil {
 			logger.Error("Failed to read file", "name", f.Name, "error", err)
-			continue
+			return
 		}
-		switch f.Name {
-		case "Distribution":
+		*remainingBytes -= int64(len(contents))
+		switch {
+		case f.Name == "Distribution":
 			logger.Debug("Found Distribution file", "size", len(contents))
 			distributionContents = contents
-		case "PackageInfo":
+		case f.Name == "PackageInfo":
 			logger.Debug("Found PackageInfo file", "size", len(contents))
 			packageInfoContents = contents
+		case f.Name == "Payload":
+			logger.Debug("Found Payload file", "size", len(contents))
+			payloadContents = contents
+		case f.Type == "file" && strings.HasSuffix(f.Name, ".pkg"):
+			logger.Debug("Found component package", "name", f.Name, "size", len(contents))
+			componentFiles = append(componentFiles, componentFile{name: f.Name, contents: contents})
 		}
-	}
+	})
 
 	// Prefer the Distribution file if present
 	if distributionContents != nil {
 		logger.Debug("Processing Distribution file")
-		if distMeta, err := parseDistributionFile(distributionContents); err != nil {
+		if distMeta, err := ParseDistributionFile(distributionContents, ParseOptions{}); err != nil {
 			logger.Error("Failed to parse Distribution", "error", err)
 		} else {
 			meta = distMeta
@@ -227,52 +381,57 @@ func ExtractXARMetadata(tfr *reader.TempFileReader) (*PKGInstallerMetadata, erro
 	if meta == nil {
 		logger.Warn("No metadata found, returning minimal metadata")
 		meta = &PKGInstallerMetadata{
-			SHA256Sum: sha256Hash.Sum(nil),
-			IsSigned:  false, // Set default value for new packages
+			IsSigned: false, // Set default value for new packages
 		}
 	} else {
-		meta.SHA256Sum = sha256Hash.Sum(nil)
 		meta.PkgSizeMB = pkgSizeMB
 		// IsSigned is already set from earlier check
 	}
 
-	// Compute additional hashes
-	if err := tfr.Rewind(); err != nil {
-		logger.Error("Failed to rewind for SHA1", "error", err)
-	} else {
-		sha1Sum, err := hash.ComputeSHA1(tfr)
-		if err != nil {
-			logger.Error("Failed to compute SHA1", "error", err)
-		} else {
-			meta.SHA1Sum = sha1Sum
-		}
+	meta.SHA256Sum = sums[crypto.SHA256]
+	meta.SHA1Sum = sums[crypto.SHA1]
+	meta.MD5Sum = sums[crypto.MD5]
+	meta.SHA512Sum = sums[crypto.SHA512]
+	meta.BLAKE2b256Sum = sums[crypto.BLAKE2b_256]
+
+	if sigInfo != nil {
+		meta.SignerCN = sigInfo.SignerCN
+		meta.SignatureAlgorithm = sigInfo.SignatureAlgorithm
+		meta.Certificates = sigInfo.Certificates
 	}
-	if err := tfr.Rewind(); err != nil {
-		logger.Error("Failed to rewind for MD5", "error", err)
-	} else {
-		md5Sum, err := hash.ComputeMD5(tfr)
-		if err != nil {
-			logger.Error("Failed to compute MD5", "error", err)
+
+	if payloadContents != nil {
+		if files, err := enumeratePayloadFiles(payloadContents, remainingBytes); err != nil {
+			logger.Error("Failed to enumerate Payload contents", "error", err)
 		} else {
-			meta.MD5Sum = md5Sum
+			meta.Files = files
 		}
 	}
-	if err := tfr.Rewind(); err != nil {
-		logger.Error("Failed to rewind for SHA256", "error", err)
-	} else {
-		sha256Sum, err := hash.ComputeSHA256(tfr)
-		if err != nil {
-			logger.Error("Failed to compute SHA256", "error", err)
+
+	if len(componentFiles) > 0 {
+		if depth >= opt.maxComponentDepth() {
+			logger.Warn("Reached max component recursion depth, not descending further",
+				"depth", depth, "skipped", len(componentFiles))
 		} else {
-			// Overwrite our previous SHA256 if needed
-			meta.SHA256Sum = sha256Sum
+			for _, cf := range componentFiles {
+				componentTfr := reader.NewTempFileReader(bytes.NewReader(cf.contents), reader.Options{})
+				componentMeta, err := extractXARMetadata(componentTfr, opt, depth+1, remainingBytes)
+				componentTfr.Close()
+				if err != nil {
+					logger.Error("Failed to extract component package", "name", cf.name, "error", err)
+					continue
+				}
+				meta.Components = append(meta.Components, componentMeta)
+			}
 		}
 	}
 
 	return meta, nil
 }
 
-func readCompressedFile(rat io.ReaderAt, heapOffset int64, sectionLength int64, f *xmlFile) ([]byte, error) {
+// readCompressedFile reads and decompresses f's heap segment, refusing to
+// produce more than maxBytes of decompressed output - see decompressEntry.
+func readCompressedFile(rat io.ReaderAt, heapOffset int64, sectionLength int64, f *xmlFile, maxBytes int64) ([]byte, error) {
 	if f == nil {
 		return nil, fmt.Errorf("nil file provided")
 	}
@@ -281,36 +440,78 @@ func readCompressedFile(rat io.ReaderAt, heapOffset int64, sectionLength int64,
 		return nil, fmt.Errorf("file has no data section")
 	}
 
-	var fileReader io.Reader
 	heapReader := io.NewSectionReader(rat, heapOffset, sectionLength-heapOffset)
-	fileReader = io.NewSectionReader(heapReader, f.Data.Offset, f.Data.Length)
-
-	// the distribution file can be compressed differently than the TOC, the
-	// actual compression is specified in the Encoding.Style field.
-	if strings.Contains(f.Data.Encoding.Style, "x-gzip") {
-		// despite the name, x-gzip fails to decode with the gzip package
-		// (invalid header), but it works with zlib.
-		logger.Debug("Using zlib decompression")
-		zr, err := zlib.NewReader(fileReader)
-		if err != nil {
-			return nil, fmt.Errorf("create zlib reader: %w", err)
-		}
-		defer zr.Close()
-		fileReader = zr
-	} else if strings.Contains(f.Data.Encoding.Style, "x-bzip2") {
-		logger.Debug("Using bzip2 decompression")
-		fileReader = bzip2.NewReader(fileReader)
-	}
-	// TODO: what other compression methods are supported?
+	fileReader := io.NewSectionReader(heapReader, f.Data.Offset, f.Data.Length)
 
-	contents, err := io.ReadAll(fileReader)
+	contents, err := decompressEntry(fileReader, f, maxBytes)
 	if err != nil {
 		return nil, fmt.Errorf("reading %s file: %w", f.Name, err)
 	}
+	return contents, nil
+}
 
+// decompressEntry decompresses r, the raw bytes of a single TOC entry's heap
+// segment, according to f's encoding, stopping with an error once more than
+// maxBytes of decompressed output has been produced. A compressed entry's
+// declared size bounds the compressed input, not the output it can expand
+// to, so without this a single maliciously (or accidentally) oversized
+// entry - Distribution, PackageInfo, Payload, or a nested component - could
+// decompress to an arbitrary amount of memory regardless of
+// ExtractOptions.MaxComponentBytes. It's shared by readCompressedFile (which
+// sections the segment out of a local heap) and the remote range-request
+// path in remote.go (which fetches the segment directly over HTTP), since
+// decompression doesn't care which one produced r.
+func decompressEntry(r io.Reader, f *xmlFile, maxBytes int64) ([]byte, error) {
+	rc, err := openEntryReader(r, f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// Read one byte past maxBytes so we can tell "exactly maxBytes" apart
+	// from "more than maxBytes" without buffering the excess.
+	contents, err := io.ReadAll(io.LimitReader(rc, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(contents)) > maxBytes {
+		return nil, fmt.Errorf("decompressed %q exceeds %d byte decompression budget", f.Name, maxBytes)
+	}
 	return contents, nil
 }
 
+// openEntryReader wraps r, the raw bytes of a single TOC entry's heap
+// segment, in a decompressing io.ReadCloser according to f's encoding - or
+// returns r unchanged (wrapped to satisfy io.ReadCloser) if no decompressor
+// applies. Unlike decompressEntry, it streams rather than buffering the
+// whole entry in memory, which is what File.Open (see archive.go) needs.
+func openEntryReader(r io.Reader, f *xmlFile) (io.ReadCloser, error) {
+	fileReader := r
+
+	// the distribution file can be compressed differently than the TOC; the
+	// actual compression is specified in the Encoding.Style field. Prefer
+	// matching that MIME-style string, but fall back to sniffing magic
+	// bytes for encodings that omit or misreport it.
+	d := decompressorByStyle(f.Data.Encoding.Style)
+	if d == nil {
+		br := bufio.NewReader(fileReader)
+		magic, _ := br.Peek(magicPeekSize)
+		d = decompressorByMagic(magic)
+		fileReader = br
+	}
+
+	if d != nil {
+		logger.Debug("Using decompressor", "name", d.Name())
+		rc, err := d.NewReader(fileReader)
+		if err != nil {
+			return nil, fmt.Errorf("create %s reader: %w", d.Name(), err)
+		}
+		return rc, nil
+	}
+
+	return io.NopCloser(fileReader), nil
+}
+
 // CheckPKGSignature checks if the provided bytes correspond to a signed pkg
 // (xar) file.
 //
@@ -329,7 +530,7 @@ func CheckPKGSignature(pkg io.Reader) error {
 	}
 
 	base := int64(hdr.HeaderSize)
-	toc, err := parseTOC(io.NewSectionReader(r, base, hdr.CompressedSize), hashType)
+	toc, _, err := parseTOC(io.NewSectionReader(r, base, hdr.CompressedSize), hashType)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,50 @@
+package xar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"testing"
+
+	"github.com/deploymenttheory/go-installer-tools/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init("error"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// TestDecompressEntryEnforcesByteBudget verifies that decompressEntry caps
+// the amount of decompressed output it will produce, rather than only
+// checking the result's size after fully decompressing it - see the
+// MaxComponentBytes budget extractXARMetadata threads through it.
+func TestDecompressEntryEnforcesByteBudget(t *testing.T) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	if err != nil {
+		t.Fatalf("create zlib writer: %v", err)
+	}
+	if _, err := zw.Write(bytes.Repeat([]byte{0}, 1<<20)); err != nil {
+		t.Fatalf("write zlib payload: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zlib writer: %v", err)
+	}
+
+	f := &xmlFile{Name: "Distribution", Data: &xmlFileData{}}
+	f.Data.Encoding.Style = "application/x-gzip"
+
+	if _, err := decompressEntry(bytes.NewReader(buf.Bytes()), f, 1024); err == nil {
+		t.Fatal("decompressEntry did not enforce the decompression budget")
+	}
+
+	contents, err := decompressEntry(bytes.NewReader(buf.Bytes()), f, 1<<20)
+	if err != nil {
+		t.Fatalf("decompressEntry rejected an entry within budget: %v", err)
+	}
+	if len(contents) != 1<<20 {
+		t.Fatalf("decompressEntry returned %d bytes, want %d", len(contents), 1<<20)
+	}
+}
@@ -2,74 +2,232 @@ package reader
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 )
 
-// TempFileReader wraps an io.Reader and provides the ability to rewind
-// by buffering content as it is read.
+// Options configures NewTempFileReader.
+type Options struct {
+	// MemoryThreshold caps how many bytes of content are buffered in
+	// memory before subsequent bytes spill to a temp file on disk. If
+	// zero, defaultMemoryThreshold is used. Ignored when the wrapped
+	// reader is an *os.File, since the file is already seekable and never
+	// needs buffering.
+	MemoryThreshold int
+}
+
+// defaultMemoryThreshold is large enough to cover the metadata files (TOC,
+// Distribution, PackageInfo) of almost any real-world package without ever
+// touching disk, while still bounding memory use for multi-GB Xcode/Office
+// installers.
+const defaultMemoryThreshold = 8 << 20 // 8 MiB
+
+func (o Options) memoryThreshold() int64 {
+	if o.MemoryThreshold == 0 {
+		return defaultMemoryThreshold
+	}
+	return int64(o.MemoryThreshold)
+}
+
+// TempFileReader wraps an io.Reader and provides the ability to rewind and
+// read at arbitrary offsets over content that has already been consumed.
+//
+// The first Options.MemoryThreshold bytes pulled from the wrapped reader are
+// kept in memory; once that threshold is crossed, subsequent bytes spill to
+// a temp file on disk, so large installers don't have to be held in memory
+// in full. Callers that spill must call Close to remove the temp file.
+//
+// Wrapping an *os.File short-circuits all of this: Read, ReadAt, Rewind,
+// and Size forward straight to the file, which is already seekable and
+// needs no buffering of its own.
 type TempFileReader struct {
-	Reader io.Reader
-	buf    *bytes.Buffer
-	tmpBuf []byte
+	reader io.Reader
+	opt    Options
+
+	file *os.File // set when reader is an *os.File; every method forwards to it directly
+
+	mem    bytes.Buffer // in-memory portion of content pulled from reader so far
+	spill  *os.File     // holds content beyond opt.memoryThreshold(); created lazily
+	filled int64        // total bytes pulled from reader so far (mem + spill)
+	pos    int64        // current position for Read
+}
+
+// NewTempFileReader wraps r so it can be rewound and read at arbitrary
+// offsets, buffering content per opts as it is read (see TempFileReader).
+func NewTempFileReader(r io.Reader, opts Options) *TempFileReader {
+	t := &TempFileReader{reader: r, opt: opts}
+	if f, ok := r.(*os.File); ok {
+		t.file = f
+	}
+	return t
 }
 
-// Read implements io.Reader interface.
-func (t *TempFileReader) Read(p []byte) (n int, err error) {
-	if t.buf == nil {
-		t.buf = &bytes.Buffer{}
+// Read implements io.Reader.
+func (t *TempFileReader) Read(p []byte) (int, error) {
+	if t.file != nil {
+		return t.file.Read(p)
 	}
-	if t.tmpBuf == nil {
-		t.tmpBuf = make([]byte, 32*1024) // 32KB buffer
+	if len(p) == 0 {
+		return 0, nil
 	}
 
-	n, err = t.Reader.Read(p)
+	// Replay already-buffered content first, so that reads after Rewind
+	// don't re-pull from the underlying reader.
+	if t.pos < t.filled {
+		return t.readStored(p, t.pos, true)
+	}
+
+	n, err := t.reader.Read(p)
 	if n > 0 {
-		// Write the data to our buffer
-		if n2, err2 := t.buf.Write(p[:n]); err2 != nil {
-			return n2, err2
+		if appendErr := t.append(p[:n]); appendErr != nil {
+			return n, appendErr
 		}
+		t.pos += int64(n)
 	}
 	return n, err
 }
 
 // Rewind resets the reader to the beginning of the buffered content.
 func (t *TempFileReader) Rewind() error {
-	if t.buf == nil {
-		t.buf = &bytes.Buffer{}
+	if t.file != nil {
+		_, err := t.file.Seek(0, io.SeekStart)
+		return err
+	}
+	t.pos = 0
+	return nil
+}
+
+// ReadAt implements io.ReaderAt, pulling more content from the wrapped
+// reader if off+len(p) hasn't been reached yet.
+func (t *TempFileReader) ReadAt(p []byte, off int64) (int, error) {
+	if t.file != nil {
+		return t.file.ReadAt(p, off)
 	}
 
-	// If the original reader is a file, try to rewind it
-	if f, ok := t.Reader.(*os.File); ok {
-		if _, err := f.Seek(0, 0); err != nil {
-			return err
+	need := off + int64(len(p))
+	buf := make([]byte, 32*1024)
+	for t.filled < need {
+		n, err := t.reader.Read(buf)
+		if n > 0 {
+			if appendErr := t.append(buf[:n]); appendErr != nil {
+				return 0, appendErr
+			}
+		}
+		if err != nil {
+			break // EOF (or a real error) - serve whatever was filled
 		}
 	}
 
-	// Create a new buffer with the existing content
-	newBuf := bytes.NewBuffer(t.buf.Bytes())
-	t.buf = newBuf
-	return nil
+	n, err := t.readStored(p, off, false)
+	if err == nil && n < len(p) {
+		err = io.EOF // io.ReaderAt requires a non-nil error on a short read
+	}
+	return n, err
 }
 
-// ReadAt implements io.ReaderAt interface
-func (t *TempFileReader) ReadAt(p []byte, off int64) (n int, err error) {
-	if t.buf == nil {
-		// If we haven't read anything yet, we need to read the entire file
-		t.buf = &bytes.Buffer{}
-		if _, err := io.Copy(t.buf, t.Reader); err != nil {
-			return 0, err
+// Size returns the number of bytes pulled from the wrapped reader so far.
+func (t *TempFileReader) Size() int64 {
+	if t.file != nil {
+		info, err := t.file.Stat()
+		if err != nil {
+			return 0
 		}
+		return info.Size()
 	}
+	return t.filled
+}
 
-	// Read from our buffer at the specified offset
-	return bytes.NewReader(t.buf.Bytes()).ReadAt(p, off)
+// Close removes the temp file created if content spilled past
+// Options.MemoryThreshold. It is always safe to call, including when
+// nothing ever spilled or the wrapped reader is an *os.File (which Close
+// never touches - callers remain responsible for closing it themselves).
+func (t *TempFileReader) Close() error {
+	if t.spill == nil {
+		return nil
+	}
+	name := t.spill.Name()
+	if err := t.spill.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
 }
 
-// Size returns the total size of the buffered content
-func (t *TempFileReader) Size() int64 {
-	if t.buf == nil {
-		return 0
+// append records b, which was just read from the wrapped reader, splitting
+// it across the in-memory buffer and the spill file at opt.memoryThreshold().
+func (t *TempFileReader) append(b []byte) error {
+	threshold := t.opt.memoryThreshold()
+	for len(b) > 0 {
+		if t.filled < threshold {
+			room := threshold - t.filled
+			chunk := b
+			if int64(len(chunk)) > room {
+				chunk = chunk[:room]
+			}
+			t.mem.Write(chunk)
+			t.filled += int64(len(chunk))
+			b = b[len(chunk):]
+			continue
+		}
+
+		if t.spill == nil {
+			f, err := os.CreateTemp("", "tempfilereader-*")
+			if err != nil {
+				return fmt.Errorf("create spill file: %w", err)
+			}
+			t.spill = f
+		}
+		n, err := t.spill.Write(b)
+		t.filled += int64(n)
+		if err != nil {
+			return fmt.Errorf("write to spill file: %w", err)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// readStored copies already-filled content in [off, off+len(p)) into p,
+// reading across the in-memory/spill boundary transparently. If advance is
+// set, t.pos is advanced by the number of bytes copied (used by Read); a
+// short read is only possible when running out of filled content, at which
+// point io.EOF is returned once off has reached t.filled.
+func (t *TempFileReader) readStored(p []byte, off int64, advance bool) (int, error) {
+	if off >= t.filled {
+		return 0, io.EOF
+	}
+	if avail := t.filled - off; int64(len(p)) > avail {
+		p = p[:avail]
+	}
+
+	threshold := t.opt.memoryThreshold()
+	n := 0
+
+	if off < threshold {
+		memEnd := threshold
+		if memEnd > t.filled {
+			memEnd = t.filled
+		}
+		chunk := p
+		if off+int64(len(chunk)) > memEnd {
+			chunk = chunk[:memEnd-off]
+		}
+		copy(chunk, t.mem.Bytes()[off:])
+		n += len(chunk)
+		off += int64(len(chunk))
+		p = p[len(chunk):]
+	}
+
+	if len(p) > 0 {
+		m, err := t.spill.ReadAt(p, off-threshold)
+		n += m
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+
+	if advance {
+		t.pos += int64(n)
 	}
-	return int64(t.buf.Len())
+	return n, nil
 }
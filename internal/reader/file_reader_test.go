@@ -0,0 +1,62 @@
+package reader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// FuzzTempFileReaderReadAt checks that TempFileReader.ReadAt, at arbitrary
+// offsets and lengths and across a variety of memory thresholds (so both
+// the in-memory and spill-file paths, and the boundary between them, are
+// exercised), returns exactly the bytes a reference bytes.Reader would for
+// the same content.
+func FuzzTempFileReaderReadAt(f *testing.F) {
+	f.Add([]byte("hello world"), int64(0), 4, 64)
+	f.Add([]byte(""), int64(0), 0, 64)
+	f.Add(bytes.Repeat([]byte{0xAB}, 1<<16), int64(1<<15), 4096, 1<<10)
+
+	f.Fuzz(func(t *testing.T, content []byte, off int64, length int, threshold int) {
+		if off < 0 || length < 0 || threshold <= 0 {
+			t.Skip()
+		}
+		// Keep inputs small enough for the fuzz loop to stay fast.
+		if length > 1<<20 {
+			length = 1 << 20
+		}
+		if off > 1<<20 {
+			off = off % (1 << 20)
+		}
+
+		tfr := NewTempFileReader(bytes.NewReader(content), Options{MemoryThreshold: threshold})
+		defer tfr.Close()
+
+		got := make([]byte, length)
+		gotN, gotErr := tfr.ReadAt(got, off)
+
+		want := make([]byte, length)
+		wantN, wantErr := bytes.NewReader(content).ReadAt(want, off)
+
+		if gotN != wantN {
+			t.Fatalf("ReadAt n = %d, want %d", gotN, wantN)
+		}
+		if !errorsEquivalent(gotErr, wantErr) {
+			t.Fatalf("ReadAt err = %v, want %v", gotErr, wantErr)
+		}
+		if !bytes.Equal(got[:gotN], want[:wantN]) {
+			t.Fatalf("ReadAt bytes = %x, want %x", got[:gotN], want[:wantN])
+		}
+	})
+}
+
+// errorsEquivalent treats io.EOF as equivalent to nil, since io.ReaderAt
+// allows either when a read ends exactly at the end of the content.
+func errorsEquivalent(a, b error) bool {
+	norm := func(err error) error {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	return norm(a) == norm(b)
+}